@@ -0,0 +1,110 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+)
+
+// First line of every ansi movie file, followed by the loop count
+const ansiMovieHeader = "AICMOVIE 1"
+
+/*
+Saves ascii art frames in the ansi movie format, which keeps the exact terminal escape codes
+of each frame, unlike a saved gif that quantizes colors. See aic_package/readme.md for the format
+*/
+func saveAnsiMovie(frames []string, delays []int, loopCount int, gifPath, urlImgName string) error {
+
+	saveFileName, err := createSaveFileName(gifPath, urlImgName, "-ascii-art.ansi")
+	if err != nil {
+		return err
+	}
+
+	fullPathName, err := getFullSavePath(saveFileName, saveMoviePath)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "%s %d\n", ansiMovieHeader, loopCount)
+	for i, frame := range frames {
+		fmt.Fprintf(&buf, "%d %d\n", delays[i], len(frame))
+		buf.WriteString(frame)
+		buf.WriteString("\n")
+	}
+
+	if err := writeSaveFile(fullPathName, buf.Bytes()); err != nil {
+		return err
+	}
+
+	fmt.Println("Saved " + fullPathName)
+	return nil
+}
+
+/*
+PlayAnsiMovie() reads an ansi movie file saved through Flags.SaveAnsiMoviePath and plays it on w,
+clearing the screen before each frame and waiting for each frame's delay. The movie is looped as
+many times as its loop count, or forever if the loop count is 0
+*/
+func PlayAnsiMovie(r io.Reader, w io.Writer) error {
+
+	reader := bufio.NewReader(r)
+
+	var loopCount int
+	if _, err := fmt.Fscanf(reader, ansiMovieHeader+" %d\n", &loopCount); err != nil {
+		return fmt.Errorf("invalid ansi movie header: %v", err)
+	}
+
+	var (
+		frames []string
+		delays []int
+	)
+
+	for {
+		var delay, length int
+		if _, err := fmt.Fscanf(reader, "%d %d\n", &delay, &length); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("invalid ansi movie frame header: %v", err)
+		}
+
+		// Frame content followed by a newline
+		frame := make([]byte, length+1)
+		if _, err := io.ReadFull(reader, frame); err != nil {
+			return fmt.Errorf("invalid ansi movie frame: %v", err)
+		}
+
+		frames = append(frames, string(frame[:length]))
+		delays = append(delays, delay)
+	}
+
+	for loop := 0; loopCount == 0 || loop < loopCount; loop++ {
+		for i, frame := range frames {
+			if _, err := fmt.Fprint(w, "\x1b[H\x1b[2J"+frame+"\n"); err != nil {
+				return err
+			}
+			time.Sleep(time.Duration((time.Second * time.Duration(delays[i])) / 100))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+/*
+Writes data to the file at fullPathName like ioutil.WriteFile(). If Flags.AtomicWrites is set, data is written
+to a temporary file in the same directory first, which is renamed to fullPathName only once all of it has been written.
+The temporary file is removed if anything fails, so that readers of the directory never see a partially written file
+*/
+func writeSaveFile(fullPathName string, data []byte) error {
+	if !atomicWrites {
+		return ioutil.WriteFile(fullPathName, data, 0666)
+	}
+
+	tempFile, err := createTempSaveFile(fullPathName)
+	if err != nil {
+		return err
+	}
+	tempPath := tempFile.Name()
+
+	if err := writeTempData(tempFile, data); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return err
+	}
+
+	// Written data has to reach the disk before the rename, otherwise a crash could still leave an incomplete file
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return err
+	}
+
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	if err := os.Rename(tempPath, fullPathName); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	return nil
+}
+
+// Writes data to the temporary file of writeSaveFile(). Tests replace it to simulate failures midway through writing
+var writeTempData = func(tempFile *os.File, data []byte) error {
+	_, err := tempFile.Write(data)
+	return err
+}
+
+/*
+Creates a hidden temporary file next to fullPathName for writeSaveFile(). Unlike ioutil.TempFile(), the file is
+created with the same permissions that ioutil.WriteFile() would give it, so renaming it doesn't change them
+*/
+func createTempSaveFile(fullPathName string) (*os.File, error) {
+	dir, name := filepath.Split(fullPathName)
+
+	for i := 0; i < 100; i++ {
+		tempPath := filepath.Join(dir, "."+name+"."+strconv.Itoa(rand.Int())+".tmp")
+
+		tempFile, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+		if !os.IsExist(err) {
+			return tempFile, err
+		}
+	}
+
+	return nil, fmt.Errorf("can't create a temporary file for %v", fullPathName)
+}
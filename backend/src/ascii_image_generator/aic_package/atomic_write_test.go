@@ -0,0 +1,98 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteSaveFileFailsMidway(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aic-atomic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Half of the data reaches the temporary file before writing fails, like it would on a full disk
+	errDiskFull := errors.New("no space left on device")
+	originalWrite := writeTempData
+	defer func() { writeTempData = originalWrite }()
+
+	writeTempData = func(tempFile *os.File, data []byte) error {
+		if _, err := tempFile.Write(data[:len(data)/2]); err != nil {
+			return err
+		}
+		return errDiskFull
+	}
+
+	atomicWrites = true
+	target := filepath.Join(dir, "art-ascii-art.txt")
+
+	if err := writeSaveFile(target, []byte("ascii art that doesn't fit")); err != errDiskFull {
+		t.Fatalf("got error %v, want %v", err, errDiskFull)
+	}
+
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("target file was left behind: %v", err)
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		t.Errorf("temporary file %v was left behind", entry.Name())
+	}
+}
+
+func TestWriteSaveFileReplacesTarget(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aic-atomic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	atomicWrites = true
+	target := filepath.Join(dir, "art-ascii-art.txt")
+
+	if err := ioutil.WriteFile(target, []byte("old"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSaveFile(target, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Errorf("target contains %q, want %q", data, "new")
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %v files, want only the target", len(entries))
+	}
+}
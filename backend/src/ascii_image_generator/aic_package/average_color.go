@@ -0,0 +1,54 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	imgManip "github.com/TheZoraiz/ascii-image-converter/image_manipulation"
+)
+
+/*
+AverageColor() takes the same arguments as Convert(), but instead of the ascii art string, it returns the
+mean RGB color of the image as it's used for conversion, i.e. after resizing to the ascii art dimensions,
+flipping, blending with Flags.BlendAlphaAgainst, Flags.Vignette and Flags.MaskPath. Flags.Negative isn't
+applied. This is a representative color of the ascii art, e.g. for sorting images or theming around them.
+
+Gifs are treated as still images and only their first frame is used. Nothing is printed or saved.
+*/
+func AverageColor(filePath string, flags Flags) ([3]uint8, error) {
+
+	if err := setFlags(flags); err != nil {
+		return [3]uint8{}, err
+	}
+
+	input, err := readInput(filePath)
+	if err != nil {
+		return [3]uint8{}, err
+	}
+	defer input.close()
+
+	imData, err := decodeImage(filePath, input)
+	if err != nil {
+		return [3]uint8{}, err
+	}
+
+	imgSet, _, err := convertImageToPixelSet(imData)
+	if err != nil {
+		return [3]uint8{}, err
+	}
+
+	return imgManip.AverageColor(imgSet), nil
+}
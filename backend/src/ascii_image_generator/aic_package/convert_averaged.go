@@ -0,0 +1,169 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	"github.com/disintegration/imaging"
+)
+
+/*
+ConvertAveraged() takes paths/urls of several images, such as a burst of photos of the same scene, and a
+aic_package.Flags literal. The images are averaged pixel by pixel into one image, which is then converted
+like Convert() does for a still image. Averaging evens out noise that differs between the images, e.g. in
+low light photos, so the ascii art comes out cleaner. The images should be aligned with each other.
+
+Images that differ in size are scaled to the width and height of the largest ones with the filter set by
+Flags.Resampling. Gifs contribute their first frame. Saving flags apply as they do for Convert(), with
+saved files named after the first path
+*/
+func ConvertAveraged(filePaths []string, flags Flags) (string, error) {
+
+	if len(filePaths) == 0 {
+		return "", fmt.Errorf("no images to average")
+	}
+
+	if err := setFlags(flags); err != nil {
+		return "", err
+	}
+
+	if err := loadFont(); err != nil {
+		return "", err
+	}
+
+	var (
+		images     = make([]image.Image, len(filePaths))
+		firstInput inputData
+		firstName  string
+	)
+
+	for i, filePath := range filePaths {
+		input, err := readInput(filePath)
+		if err != nil {
+			return "", err
+		}
+
+		images[i], err = decodeImage(filePath, input)
+		input.close()
+		if err != nil {
+			return "", err
+		}
+
+		if i == 0 {
+			firstInput = input
+			firstName = inputName
+		}
+	}
+
+	inputName = firstName
+	inputIsGif = false
+
+	return convertDecodedImage(convertColorModel(averageImages(images)), filePaths[0], firstInput)
+}
+
+/*
+ConvertGifAveraged() works like ConvertAveraged(), but averages the frames of the gif at filePath, e.g. to
+clean up a noisy recording of a still scene. Frames are averaged as a viewer shows them, after being placed
+on the gif's logical screen. Flags.StartTime and Flags.Duration pick which frames are averaged
+*/
+func ConvertGifAveraged(filePath string, flags Flags) (string, error) {
+
+	if err := setFlags(flags); err != nil {
+		return "", err
+	}
+
+	input, err := readInput(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer input.close()
+
+	if !inputIsGif {
+		return "", fmt.Errorf("can't average frames of %v, since it isn't a gif", filePath)
+	}
+
+	if err := loadFont(); err != nil {
+		return "", err
+	}
+
+	originalGif, err := decodeGif(filePath, input)
+	if err != nil {
+		return "", err
+	}
+
+	frames, _, err := clipGifFrames(compositeGifFrames(originalGif), originalGif.Delay)
+	if err != nil {
+		return "", err
+	}
+
+	inputIsGif = false
+
+	return convertDecodedImage(convertColorModel(averageImages(frames)), filePath, input)
+}
+
+// Returns the mean of each premultiplied color and alpha value across images, after scaling them to one size
+func averageImages(images []image.Image) image.Image {
+
+	images = normalizeFrameSizes(images)
+
+	b := images[0].Bounds()
+	frame := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	sums := make([]uint32, len(frame.Pix))
+
+	for _, img := range images {
+		draw.Draw(frame, frame.Rect, img, img.Bounds().Min, draw.Src)
+
+		for i, value := range frame.Pix {
+			sums[i] += uint32(value)
+		}
+	}
+
+	count := uint32(len(images))
+	for i, sum := range sums {
+		frame.Pix[i] = uint8((sum + count/2) / count)
+	}
+
+	return frame
+}
+
+// Scales frames that differ in size to the width and height of the largest ones, with the filter set by Flags.Resampling
+func normalizeFrameSizes(frames []image.Image) []image.Image {
+
+	var maxWidth, maxHeight int
+	for _, frame := range frames {
+		if frame.Bounds().Dx() > maxWidth {
+			maxWidth = frame.Bounds().Dx()
+		}
+		if frame.Bounds().Dy() > maxHeight {
+			maxHeight = frame.Bounds().Dy()
+		}
+	}
+
+	normalizedFrames := make([]image.Image, len(frames))
+	for i, frame := range frames {
+		if frame.Bounds().Dx() == maxWidth && frame.Bounds().Dy() == maxHeight {
+			normalizedFrames[i] = frame
+			continue
+		}
+		normalizedFrames[i] = imaging.Resize(frame, maxWidth, maxHeight, resampleFilter)
+	}
+
+	return normalizedFrames
+}
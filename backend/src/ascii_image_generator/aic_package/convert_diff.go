@@ -0,0 +1,156 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import "strings"
+
+// Escape codes wrapping runs of differing characters in DiffAscii()
+const (
+	diffHighlightStart = "\x1b[41m"
+	diffHighlightEnd   = "\x1b[0m"
+)
+
+/*
+DiffAscii() compares two ascii art strings character by character and returns b with the characters that
+differ from a highlighted with a red background. Terminal escape codes, like colors, are removed from both
+before comparing, so only the characters themselves are compared.
+
+If the ascii arts have different dimensions, the result has as many lines and columns as the larger of
+the two. Cells that only one of them has are highlighted, showing the character from whichever has it.
+*/
+func DiffAscii(a, b string) string {
+
+	linesA := strings.Split(stripEscapeCodes(a), "\n")
+	linesB := strings.Split(stripEscapeCodes(b), "\n")
+
+	rows := len(linesA)
+	if len(linesB) > rows {
+		rows = len(linesB)
+	}
+
+	result := make([]string, rows)
+
+	for y := 0; y < rows; y++ {
+		var rowA, rowB []rune
+		if y < len(linesA) {
+			rowA = []rune(linesA[y])
+		}
+		if y < len(linesB) {
+			rowB = []rune(linesB[y])
+		}
+
+		cols := len(rowA)
+		if len(rowB) > cols {
+			cols = len(rowB)
+		}
+
+		var line strings.Builder
+		highlighted := false
+
+		for x := 0; x < cols; x++ {
+			var char rune
+			differs := x >= len(rowA) || x >= len(rowB) || rowA[x] != rowB[x]
+
+			if x < len(rowB) {
+				char = rowB[x]
+			} else {
+				char = rowA[x]
+			}
+
+			// Consecutive differing characters share one pair of escape codes
+			if differs && !highlighted {
+				line.WriteString(diffHighlightStart)
+			} else if !differs && highlighted {
+				line.WriteString(diffHighlightEnd)
+			}
+			highlighted = differs
+
+			line.WriteRune(char)
+		}
+
+		if highlighted {
+			line.WriteString(diffHighlightEnd)
+		}
+
+		result[y] = line.String()
+	}
+
+	return strings.Join(result, "\n")
+}
+
+/*
+ConvertDiff() converts the image at filePath once with each of flagsA and flagsB, and returns the two ascii
+arts compared through DiffAscii(). This shows how changing flags affects the ascii art.
+
+Gifs are compared by their first frame, as if Flags.ForceStatic was set
+*/
+func ConvertDiff(filePath string, flagsA, flagsB Flags) (string, error) {
+
+	flagsA.ForceStatic = true
+	flagsB.ForceStatic = true
+
+	asciiA, err := Convert(filePath, flagsA)
+	if err != nil {
+		return "", err
+	}
+
+	asciiB, err := Convert(filePath, flagsB)
+	if err != nil {
+		return "", err
+	}
+
+	return DiffAscii(asciiA, asciiB), nil
+}
+
+// Removes CSI and OSC escape sequences, along with other two character escape sequences, from ascii
+func stripEscapeCodes(ascii string) string {
+
+	var stripped strings.Builder
+	runes := []rune(ascii)
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '\x1b' {
+			stripped.WriteRune(runes[i])
+			continue
+		}
+
+		if i+1 >= len(runes) {
+			break
+		}
+		i++
+
+		switch runes[i] {
+		case '[':
+			// Parameters are followed by a final character from @ to ~
+			for i+1 < len(runes) && (runes[i+1] < '@' || runes[i+1] > '~') {
+				i++
+			}
+			i++
+		case ']':
+			// Terminated by BEL or ESC \
+			for i+1 < len(runes) && runes[i+1] != '\a' && runes[i+1] != '\x1b' {
+				i++
+			}
+			i++
+			if i < len(runes) && runes[i] == '\x1b' {
+				i++
+			}
+		}
+	}
+
+	return stripped.String()
+}
@@ -23,6 +23,7 @@ import (
 	"image/color/palette"
 	"image/draw"
 	"image/gif"
+	"math"
 	"os"
 	"runtime"
 	"strconv"
@@ -38,38 +39,38 @@ type GifFrame struct {
 	delay        int
 }
 
-/*
-This function grabs each image frame from passed gif and turns it into ascii art. If SaveGifPath flag is passed,
-it'll turn each ascii art into an image instance of the same dimensions as the original gif and save them
-as an ascii art gif.
+// This function grabs each image frame from passed gif and turns it into ascii art through convertFrames()
+func pathIsGif(gifPath string, input inputData) error {
 
-Multi-threading has been implemented in multiple places due to long execution time
-*/
-func pathIsGif(gifPath, urlImgName string, pathIsURl bool, urlImgBytes, pipedInputBytes []byte, localGif *os.File) error {
+	originalGif, err := decodeGif(gifPath, input)
+	if err != nil {
+		return err
+	}
 
-	var (
-		originalGif *gif.GIF
-		err         error
-	)
+	// Frames of optimized gifs are patches placed on the logical screen, so each
+	// one is drawn over the previous ones before conversion
+	compositedFrames := compositeGifFrames(originalGif)
 
-	if gifPath == "-" {
-		originalGif, err = gif.DecodeAll(bytes.NewReader(pipedInputBytes))
-	} else if pathIsURl {
-		originalGif, err = gif.DecodeAll(bytes.NewReader(urlImgBytes))
-	} else {
-		originalGif, err = gif.DecodeAll(localGif)
-	}
+	compositedFrames, delays, err := clipGifFrames(compositedFrames, originalGif.Delay)
 	if err != nil {
-		if gifPath == "-" {
-			return fmt.Errorf("can't decode piped input: %v", err)
-		} else {
-			return fmt.Errorf("can't decode %v: %v", gifPath, err)
-		}
+		return err
 	}
 
+	return convertFrames(compositedFrames, delays, originalGif.LoopCount, gifPath, input)
+}
+
+/*
+This function turns each passed frame into ascii art and displays them as an animation, with delays in 100ths
+of a second, that plays loopCount times (0 loops forever). If SaveGifPath flag is passed, it'll turn each ascii
+art into an image instance of the same dimensions as the original frame and save them as an ascii art gif.
+
+Multi-threading has been implemented in multiple places due to long execution time
+*/
+func convertFrames(compositedFrames []image.Image, delays []int, gifLoopCount int, gifPath string, input inputData) error {
+
 	var (
-		asciiArtSet    = make([]string, len(originalGif.Image))
-		gifFramesSlice = make([]GifFrame, len(originalGif.Image))
+		asciiArtSet    = make([]string, len(compositedFrames))
+		gifFramesSlice = make([]GifFrame, len(compositedFrames))
 
 		counter             = 0
 		concurrentProcesses = 0
@@ -79,60 +80,38 @@ func pathIsGif(gifPath, urlImgName string, pathIsURl bool, urlImgBytes, pipedInp
 
 	fmt.Printf("Generating ascii art... 0%%\r")
 
-	// Get first frame of gif and its dimensions
-	firstGifFrame := originalGif.Image[0].SubImage(originalGif.Image[0].Rect)
-	firstGifFrameWidth := firstGifFrame.Bounds().Dx()
-	firstGifFrameHeight := firstGifFrame.Bounds().Dy()
-
 	// Multi-threaded loop to decrease execution time
-	for i, frame := range originalGif.Image {
+	for i, frame := range compositedFrames {
+
+		// Keep the frames converted so far, as described for Flags.TimeBudget
+		if i > 0 && budgetExceeded() {
+			wg.Wait()
+			compositedFrames = compositedFrames[:i]
+			gifFramesSlice = gifFramesSlice[:i]
+			asciiArtSet = asciiArtSet[:i]
+			break
+		}
 
 		wg.Add(1)
 		concurrentProcesses++
 
-		go func(i int, frame *image.Paletted) {
-
-			frameImage := frame.SubImage(frame.Rect)
+		go func(i int, frame image.Image) {
 
-			// If a frame is found that is smaller than the first frame, then this gif contains smaller subimages that are
-			// positioned inside the original gif. This behavior isn't supported by this app
-			if firstGifFrameWidth != frameImage.Bounds().Dx() || firstGifFrameHeight != frameImage.Bounds().Dy() {
-				if urlImgName == "" {
-					fmt.Printf("Error: " + gifPath + " contains subimages smaller than default width and height\n\nProcess aborted because ascii-image-converter doesn't support subimage placement and transparency in GIFs\n\n")
-				} else {
-					fmt.Printf("Error: " + urlImgName + " contains subimages smaller than default width and height\n\nProcess aborted because ascii-image-converter doesn't support subimage placement and transparency in GIFs\n\n")
-				}
-				os.Exit(0)
-			}
-
-			var imgSet [][]imgManip.AsciiPixel
-
-			imgSet, err = imgManip.ConvertToAsciiPixels(frameImage, dimensions, width, height, flipX, flipY, full, braille, dither)
-			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				os.Exit(0)
-			}
-
-			var asciiCharSet [][]imgManip.AsciiChar
-			if braille {
-				asciiCharSet, err = imgManip.ConvertToBrailleChars(imgSet, negative, colored, grayscale, colorBg, fontColor, threshold)
-			} else {
-				asciiCharSet, err = imgManip.ConvertToAsciiChars(imgSet, negative, colored, grayscale, complex, colorBg, customMap, fontColor)
-			}
+			asciiCharSet, err := convertImageToAsciiSet(convertColorModel(frame))
 			if err != nil {
 				fmt.Printf("Error: %v\n", err)
 				os.Exit(0)
 			}
 
 			gifFramesSlice[i].asciiCharSet = asciiCharSet
-			gifFramesSlice[i].delay = originalGif.Delay[i]
+			gifFramesSlice[i].delay = delays[i]
 
 			ascii := flattenAscii(asciiCharSet, colored || grayscale, false)
 
 			asciiArtSet[i] = strings.Join(ascii, "\n")
 
 			counter++
-			percentage := int((float64(counter) / float64(len(originalGif.Image))) * 100)
+			percentage := int((float64(counter) / float64(len(compositedFrames))) * 100)
 			fmt.Printf("Generating ascii art... " + strconv.Itoa(percentage) + "%%\r")
 
 			wg.Done()
@@ -149,13 +128,30 @@ func pathIsGif(gifPath, urlImgName string, pathIsURl bool, urlImgBytes, pipedInp
 	wg.Wait()
 	fmt.Printf("                              \r")
 
+	// Frames are normalized to the same size, so the first one stands for all of them
+	if len(gifFramesSlice) > 0 {
+		recordConvertedSize(gifFramesSlice[0].asciiCharSet)
+	}
+
+	// Order in which converted frames are played or saved, along with their delays
+	frameOrder := make([]int, len(gifFramesSlice))
+	frameDelays := make([]int, len(gifFramesSlice))
+	for i, gifFrame := range gifFramesSlice {
+		frameOrder[i] = i
+		frameDelays[i] = gifFrame.delay
+	}
+
+	if targetFPS > 0 {
+		frameOrder, frameDelays = resampleFrames(frameDelays, targetFPS)
+	}
+
 	// Save ascii art as .gif file before displaying it, if --save-gif flag is passed
 	if saveGifPath != "" {
 
 		// Storing save path string before executing ascii art to gif conversion
 		// This is done to avoid wasting time for invalid path errors
 
-		saveFileName, err := createSaveFileName(gifPath, urlImgName, "-ascii-art.gif")
+		saveFileName, err := createSaveFileName(gifPath, input.urlImgName, "-ascii-art.gif")
 		if err != nil {
 			return err
 		}
@@ -167,18 +163,15 @@ func pathIsGif(gifPath, urlImgName string, pathIsURl bool, urlImgBytes, pipedInp
 
 		// Initializing some constants for gif. Done outside loop to save execution
 		outGif := &gif.GIF{
-			LoopCount: originalGif.LoopCount,
+			LoopCount: gifLoopCount,
 		}
 		opts := gif.Options{
 			NumColors: 256,
 			Drawer:    draw.FloydSteinberg,
 		}
 
-		// Initializing slices for each ascii art image as well as delay
-		var (
-			palettedImageSlice = make([]*image.Paletted, len(gifFramesSlice))
-			delaySlice         = make([]int, len(gifFramesSlice))
-		)
+		// Initializing slice for each ascii art image
+		palettedImageSlice := make([]*image.Paletted, len(gifFramesSlice))
 
 		// For the purpose of displaying counter and limiting concurrent processes
 		counter = 0
@@ -194,11 +187,9 @@ func pathIsGif(gifPath, urlImgName string, pathIsURl bool, urlImgBytes, pipedInp
 
 			go func(i int, gifFrame GifFrame) {
 
-				img := originalGif.Image[i].SubImage(originalGif.Image[i].Rect)
-
 				tempImg, err := createGifFrameToSave(
 					gifFrame.asciiCharSet,
-					img,
+					compositedFrames[i],
 					colored || grayscale,
 				)
 				if err != nil {
@@ -214,7 +205,6 @@ func pathIsGif(gifPath, urlImgName string, pathIsURl bool, urlImgBytes, pipedInp
 				opts.Drawer.Draw(palettedImg, b, tempImg, image.Point{})
 
 				palettedImageSlice[i] = palettedImg
-				delaySlice[i] = gifFrame.delay
 
 				counter++
 				percentage := int((float64(counter) / float64(len(gifFramesSlice))) * 100)
@@ -234,43 +224,277 @@ func pathIsGif(gifPath, urlImgName string, pathIsURl bool, urlImgBytes, pipedInp
 
 		wg.Wait()
 
-		outGif.Image = palettedImageSlice
-		outGif.Delay = delaySlice
+		outGif.Image = make([]*image.Paletted, len(frameOrder))
+		for i, frameIndex := range frameOrder {
+			outGif.Image[i] = palettedImageSlice[frameIndex]
+		}
+		outGif.Delay = make([]int, len(frameDelays))
+		for i, delay := range frameDelays {
+			if delay < minFrameDelay {
+				delay = minFrameDelay
+			}
+			outGif.Delay[i] = delay
+		}
 
-		gifFile, err := os.OpenFile(fullPathName, os.O_WRONLY|os.O_CREATE, 0666)
-		if err != nil {
+		var gifBuf bytes.Buffer
+		if err := gif.EncodeAll(&gifBuf, outGif); err != nil {
 			return fmt.Errorf("can't save file: %v", err)
 		}
-		defer gifFile.Close()
 
-		gif.EncodeAll(gifFile, outGif)
+		if err := writeSaveFile(fullPathName, gifBuf.Bytes()); err != nil {
+			return fmt.Errorf("can't save file: %v", err)
+		}
 
 		fmt.Printf("                     \r")
 
 		fmt.Println("Saved " + fullPathName)
 	}
 
+	// Save ascii art frames along with their delays as an ansi movie file, if --save-movie flag is passed
+	if saveMoviePath != "" {
+		frames := make([]string, len(frameOrder))
+		for i, frameIndex := range frameOrder {
+			frames[i] = asciiArtSet[frameIndex]
+		}
+
+		if err := saveAnsiMovie(frames, frameDelays, gifLoopCount, gifPath, input.urlImgName); err != nil {
+			return fmt.Errorf("can't save file: %v", err)
+		}
+	}
+
 	// Display the gif
 	if !onlySave {
+		titleStart, titleEnd := getTerminalTitleSequences()
+		fmt.Print(titleStart)
+		if titleEnd != "" {
+			stopRestoring := restoreTitleOnInterrupt(titleEnd)
+			defer stopRestoring()
+		}
+
 		loopCount := 0
 		for {
-			for i, asciiFrame := range asciiArtSet {
-				clearScreen()
-				fmt.Println(asciiFrame)
-				time.Sleep(time.Duration((time.Second * time.Duration(originalGif.Delay[i])) / 100))
+			for i, frameIndex := range frameOrder {
+				asciiFrame := asciiArtSet[frameIndex]
+				if cursorSaveRest && isOutputTerminal() {
+					fmt.Print(saveRestoreCursor(asciiFrame + "\n"))
+				} else {
+					clearScreen()
+					fmt.Println(asciiFrame)
+				}
+				time.Sleep(time.Duration((time.Second * time.Duration(frameDelays[i])) / 100))
 			}
 
 			// If gif is infinite loop
-			if originalGif.LoopCount == 0 {
+			if gifLoopCount == 0 {
 				continue
 			}
 
 			loopCount++
-			if loopCount == originalGif.LoopCount {
+			if loopCount == gifLoopCount {
 				break
 			}
 		}
+
+		fmt.Print(titleEnd)
 	}
 
 	return nil
 }
+
+/*
+Returns the frames, along with their delays, that are shown within the time window set through Flags.StartTime
+and Flags.Duration. Delays of frames at the edges of the window are cut to the part inside it. All frames
+are returned as they are if neither flag is set
+*/
+func clipGifFrames(frames []image.Image, delays []int) ([]image.Image, []int, error) {
+
+	if startTime == 0 && clipDuration == 0 {
+		return frames, delays, nil
+	}
+
+	totalDuration := 0
+	for _, delay := range delays {
+		totalDuration += delay
+	}
+
+	// Gif delays are in 100ths of a second
+	windowStart := int(startTime / (10 * time.Millisecond))
+	windowEnd := totalDuration
+	if clipDuration > 0 {
+		windowEnd = windowStart + int(clipDuration/(10*time.Millisecond))
+		if windowEnd > totalDuration {
+			windowEnd = totalDuration
+		}
+	}
+
+	// Without any delays, the whole gif is shown at its start
+	if totalDuration == 0 && windowStart == 0 {
+		return frames, delays, nil
+	}
+
+	if windowStart >= totalDuration {
+		return nil, nil, fmt.Errorf("start time %v exceeds gif duration of %v", startTime, time.Duration(totalDuration)*10*time.Millisecond)
+	}
+
+	var (
+		clippedFrames []image.Image
+		clippedDelays []int
+		frameStart    = 0
+	)
+
+	for i, delay := range delays {
+		frameEnd := frameStart + delay
+
+		if frameEnd > windowStart && frameStart < windowEnd {
+			shownStart, shownEnd := frameStart, frameEnd
+			if shownStart < windowStart {
+				shownStart = windowStart
+			}
+			if shownEnd > windowEnd {
+				shownEnd = windowEnd
+			}
+
+			clippedFrames = append(clippedFrames, frames[i])
+			clippedDelays = append(clippedDelays, shownEnd-shownStart)
+		}
+
+		frameStart = frameEnd
+	}
+
+	// Window shorter than 100th of a second still shows the frame at its start
+	if len(clippedFrames) == 0 {
+		for i, delay := range delays {
+			if windowStart < delay {
+				return []image.Image{frames[i]}, []int{0}, nil
+			}
+			windowStart -= delay
+		}
+	}
+
+	return clippedFrames, clippedDelays, nil
+}
+
+/*
+Resamples gif frames with the passed delays (in 100ths of a second) to a constant frame rate. For each
+tick of 1/fps seconds, the frame that's active at that time in the original gif is picked. Returns
+the indexes of picked frames along with their delays, which are rounded so that they don't drift
+from the original timing
+*/
+func resampleFrames(delays []int, fps float64) ([]int, []int) {
+
+	tick := 100 / fps
+
+	totalDuration := 0
+	for _, delay := range delays {
+		totalDuration += delay
+	}
+
+	// Without any delays, there's no timing to resample from
+	if totalDuration == 0 {
+		frameOrder := make([]int, len(delays))
+		frameDelays := make([]int, len(delays))
+		for i := range delays {
+			frameOrder[i] = i
+			frameDelays[i] = int(math.Round(tick))
+		}
+		return frameOrder, frameDelays
+	}
+
+	var (
+		frameOrder  []int
+		frameDelays []int
+
+		currentFrame = 0
+		frameEnd     = delays[0]
+	)
+
+	for tickCount := 0; float64(tickCount)*tick < float64(totalDuration); tickCount++ {
+		tickTime := float64(tickCount) * tick
+
+		for float64(frameEnd) <= tickTime {
+			currentFrame++
+			frameEnd += delays[currentFrame]
+		}
+
+		frameOrder = append(frameOrder, currentFrame)
+		frameDelays = append(frameDelays, int(math.Round(tickTime+tick))-int(math.Round(tickTime)))
+	}
+
+	return frameOrder, frameDelays
+}
+
+// Decodes all frames of the gif from whichever source readInput() retrieved it from
+func decodeGif(gifPath string, input inputData) (*gif.GIF, error) {
+
+	var (
+		originalGif *gif.GIF
+		err         error
+	)
+
+	if gifPath == "-" {
+		originalGif, err = gif.DecodeAll(bytes.NewReader(input.pipedInputBytes))
+	} else if input.pathIsURl {
+		originalGif, err = gif.DecodeAll(bytes.NewReader(input.urlImgBytes))
+	} else {
+		originalGif, err = gif.DecodeAll(input.localFile)
+	}
+	if err != nil {
+		if gifPath == "-" {
+			return nil, fmt.Errorf("can't decode piped input: %v", err)
+		} else {
+			return nil, fmt.Errorf("can't decode %v: %v", gifPath, err)
+		}
+	}
+
+	return originalGif, nil
+}
+
+/*
+Draws each frame of passed gif onto a canvas the size of the gif's logical screen, at the frame's
+offset, and returns a copy of the canvas after each frame. Disposal methods are honored between
+frames, so that every returned image looks like what a viewer shows at that point of the animation
+*/
+func compositeGifFrames(originalGif *gif.GIF) []image.Image {
+
+	canvasRect := image.Rect(0, 0, originalGif.Config.Width, originalGif.Config.Height)
+	if canvasRect.Empty() {
+		// Logical screen size is missing, so it's taken from the frames instead
+		for _, frame := range originalGif.Image {
+			canvasRect = canvasRect.Union(frame.Rect)
+		}
+	}
+
+	var (
+		canvas           = image.NewRGBA(canvasRect)
+		compositedFrames = make([]image.Image, len(originalGif.Image))
+	)
+
+	for i, frame := range originalGif.Image {
+
+		disposal := byte(gif.DisposalNone)
+		if i < len(originalGif.Disposal) {
+			disposal = originalGif.Disposal[i]
+		}
+
+		var previousCanvas *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previousCanvas = image.NewRGBA(canvasRect)
+			copy(previousCanvas.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Rect, frame, frame.Rect.Min, draw.Over)
+
+		composited := image.NewRGBA(canvasRect)
+		copy(composited.Pix, canvas.Pix)
+		compositedFrames[i] = composited
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Rect, image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previousCanvas
+		}
+	}
+
+	return compositedFrames
+}
@@ -0,0 +1,203 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+
+	imgManip "github.com/TheZoraiz/ascii-image-converter/image_manipulation"
+)
+
+var (
+	testRed   = color.RGBA{255, 0, 0, 255}
+	testBlue  = color.RGBA{0, 0, 255, 255}
+	testGreen = color.RGBA{0, 255, 0, 255}
+	testClear = color.RGBA{0, 0, 0, 0}
+)
+
+// Returns a frame covering rect, filled with c
+func filledFrame(rect image.Rectangle, c color.Color) *image.Paletted {
+	frame := image.NewPaletted(rect, color.Palette{testClear, testRed, testBlue, testGreen})
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			frame.Set(x, y, c)
+		}
+	}
+	return frame
+}
+
+func TestCompositeGifFramesWithOffsetPatches(t *testing.T) {
+	// A full red first frame, then small patches at offsets like optimized gifs store them
+	originalGif := &gif.GIF{
+		Config: image.Config{Width: 4, Height: 2},
+		Image: []*image.Paletted{
+			filledFrame(image.Rect(0, 0, 4, 2), testRed),
+			filledFrame(image.Rect(2, 1, 4, 2), testBlue),
+			filledFrame(image.Rect(0, 0, 1, 1), testGreen),
+			filledFrame(image.Rect(3, 0, 4, 1), testBlue),
+		},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalNone, gif.DisposalBackground, gif.DisposalNone},
+	}
+
+	// Expected canvas after each frame, row by row
+	want := [][2][4]color.RGBA{
+		{{testRed, testRed, testRed, testRed}, {testRed, testRed, testRed, testRed}},
+		{{testRed, testRed, testRed, testRed}, {testRed, testRed, testBlue, testBlue}},
+		{{testGreen, testRed, testRed, testRed}, {testRed, testRed, testBlue, testBlue}},
+		// The green patch was disposed to background, so its pixel is transparent
+		{{testClear, testRed, testRed, testBlue}, {testRed, testRed, testBlue, testBlue}},
+	}
+
+	frames := compositeGifFrames(originalGif)
+	if len(frames) != len(want) {
+		t.Fatalf("got %v frames, want %v", len(frames), len(want))
+	}
+
+	for i, frame := range frames {
+		if frame.Bounds() != image.Rect(0, 0, 4, 2) {
+			t.Errorf("frame %v: got bounds %v, want the 4x2 logical screen", i, frame.Bounds())
+			continue
+		}
+		for y := 0; y < 2; y++ {
+			for x := 0; x < 4; x++ {
+				if got := color.RGBAModel.Convert(frame.At(x, y)); got != want[i][y][x] {
+					t.Errorf("frame %v, pixel %v,%v: got %v, want %v", i, x, y, got, want[i][y][x])
+				}
+			}
+		}
+	}
+}
+
+func TestNormalizeFrameSizes(t *testing.T) {
+	resampleFilter = resampleFilters["nearest"]
+	defer func() { resampleFilter = resampleFilters["lanczos"] }()
+
+	frames := []image.Image{
+		filledFrame(image.Rect(0, 0, 10, 5), testRed),
+		filledFrame(image.Rect(0, 0, 20, 4), testBlue),
+		filledFrame(image.Rect(0, 0, 20, 8), testGreen),
+	}
+
+	normalized := normalizeFrameSizes(frames)
+
+	for i, frame := range normalized {
+		if frame.Bounds().Dx() != 20 || frame.Bounds().Dy() != 8 {
+			t.Errorf("frame %v: got %v, want 20x8", i, frame.Bounds().Size())
+		}
+	}
+
+	// Frames that already have the largest size are kept as they are
+	if normalized[2] != frames[2] {
+		t.Errorf("frame 2 was copied although it's already 20x8")
+	}
+
+	// Scaled frames keep their colors throughout
+	if got := color.RGBAModel.Convert(normalized[0].At(19, 7)); got != testRed {
+		t.Errorf("frame 0 corner: got %v, want %v", got, testRed)
+	}
+}
+
+func TestGifFramesOfDifferingSizes(t *testing.T) {
+	// A full red first frame followed by a smaller blue patch in its upper left corner
+	originalGif := &gif.GIF{
+		Config: image.Config{Width: 4, Height: 2},
+		Image: []*image.Paletted{
+			filledFrame(image.Rect(0, 0, 4, 2), testRed),
+			filledFrame(image.Rect(0, 0, 1, 1), testBlue),
+		},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalNone},
+	}
+
+	// Each character covers 2x2 pixels. Nearest neighbor samples the red pixel at 1,1 for the first
+	// one, while box resampling averages the blue patch into it
+	tests := []struct {
+		resampling string
+		want       [3]uint32
+	}{
+		{"nearest", [3]uint32{255, 0, 0}},
+		{"box", [3]uint32{192, 0, 64}},
+	}
+
+	for _, test := range tests {
+		flags := DefaultFlags()
+		flags.Dimensions = []int{2, 1}
+		flags.Colored = true
+		flags.Resampling = test.resampling
+		if err := setFlags(flags); err != nil {
+			t.Fatal(err)
+		}
+
+		var asciiSets [][][]imgManip.AsciiChar
+		for _, frame := range compositeGifFrames(originalGif) {
+			asciiSet, err := convertImageToAsciiSet(convertColorModel(frame))
+			if err != nil {
+				t.Fatal(err)
+			}
+			asciiSets = append(asciiSets, asciiSet)
+		}
+
+		for i, asciiSet := range asciiSets {
+			if len(asciiSet) != 1 || len(asciiSet[0]) != 2 {
+				t.Fatalf("%v, frame %v: got %vx%v ascii art, want 2x1", test.resampling, i, len(asciiSet[0]), len(asciiSet))
+			}
+		}
+
+		if got := asciiSets[1][0][0].RgbValue; got != test.want {
+			t.Errorf("%v: got color %v for the patched character, want %v", test.resampling, got, test.want)
+		}
+		if got := asciiSets[1][0][1].RgbValue; got != [3]uint32{255, 0, 0} {
+			t.Errorf("%v: got color %v for the unpatched character, want red", test.resampling, got)
+		}
+	}
+}
+
+func TestTargetFPSLimit(t *testing.T) {
+	tests := []struct {
+		fps     float64
+		wantErr bool
+	}{
+		{0, false},
+		{100, false},
+		{100.5, true},
+		{1000, true},
+	}
+
+	for _, test := range tests {
+		flags := DefaultFlags()
+		flags.TargetFPS = test.fps
+		if err := setFlags(flags); (err != nil) != test.wantErr {
+			t.Errorf("fps %v: got error %v, want error: %v", test.fps, err, test.wantErr)
+		}
+	}
+
+	// At the maximum, a 1 second gif of two frames becomes 100 frames of 1/100th of a second each
+	frameOrder, frameDelays := resampleFrames([]int{50, 50}, 100)
+	if len(frameOrder) != 100 {
+		t.Fatalf("got %v frames, want 100", len(frameOrder))
+	}
+	for i, delay := range frameDelays {
+		if delay != 1 {
+			t.Fatalf("frame %v: got delay %v, want 1", i, delay)
+		}
+	}
+	if frameOrder[49] != 0 || frameOrder[50] != 1 {
+		t.Errorf("frames 49 and 50 are %v and %v, want 0 and 1", frameOrder[49], frameOrder[50])
+	}
+}
@@ -20,59 +20,58 @@ import (
 	"bytes"
 	"fmt"
 	"image"
+	"image/draw"
+	"io"
+	"io/ioutil"
+	"math"
 	"os"
+
 	"strings"
+	"time"
 
 	imgManip "github.com/TheZoraiz/ascii-image-converter/image_manipulation"
+	"github.com/disintegration/imaging"
 )
 
 // This function decodes the passed image and returns an ascii art string, optionaly saving it as a .txt and/or .png file
-func pathIsImage(imagePath, urlImgName string, pathIsURl bool, urlImgBytes, pipedInputBytes []byte, localImg *os.File) (string, error) {
-
-	var (
-		imData image.Image
-		err    error
-	)
-
-	if imagePath == "-" {
-		imData, _, err = image.Decode(bytes.NewReader(pipedInputBytes))
-	} else if pathIsURl {
-		imData, _, err = image.Decode(bytes.NewReader(urlImgBytes))
-	} else {
-		imData, _, err = image.Decode(localImg)
-	}
-	if err != nil {
-		if imagePath == "-" {
-			return "", fmt.Errorf("can't decode piped input: %v", err)
-		} else {
-			return "", fmt.Errorf("can't decode %v: %v", imagePath, err)
-		}
-	}
+func pathIsImage(imagePath string, input inputData) (string, error) {
 
-	imgSet, err := imgManip.ConvertToAsciiPixels(imData, dimensions, width, height, flipX, flipY, full, braille, dither)
+	imData, err := decodeImage(imagePath, input)
 	if err != nil {
 		return "", err
 	}
 
-	var asciiSet [][]imgManip.AsciiChar
+	return convertDecodedImage(imData, imagePath, input)
+}
 
-	if braille {
-		asciiSet, err = imgManip.ConvertToBrailleChars(imgSet, negative, colored, grayscale, colorBg, fontColor, threshold)
-	} else {
-		asciiSet, err = imgManip.ConvertToAsciiChars(imgSet, negative, colored, grayscale, complex, colorBg, customMap, fontColor)
+// Turns decoded image data into the returned ascii art, saving it first if saving flags are passed
+func convertDecodedImage(imData image.Image, imagePath string, input inputData) (string, error) {
+
+	if timeBudget > 0 && time.Since(conversionStart) > timeBudget/2 {
+		imData = shrinkForBudget(imData)
 	}
+
+	asciiSet, err := convertImageToAsciiSet(imData)
 	if err != nil {
 		return "", err
 	}
+	recordConvertedSize(asciiSet)
 
 	// Save ascii art as .png image before printing it, if --save-img flag is passed
 	if saveImagePath != "" {
+		imageSet := asciiSet
+		if aspectFromFont && len(dimensions) == 0 {
+			if imageSet, err = convertImageToSavedAsciiSet(imData); err != nil {
+				return "", err
+			}
+		}
+
 		if err := createImageToSave(
-			asciiSet,
+			imageSet,
 			colored || grayscale,
 			saveImagePath,
 			imagePath,
-			urlImgName,
+			input.urlImgName,
 			onlySave,
 		); err != nil {
 
@@ -86,7 +85,7 @@ func pathIsImage(imagePath, urlImgName string, pathIsURl bool, urlImgBytes, pipe
 			asciiSet,
 			imagePath,
 			saveTxtPath,
-			urlImgName,
+			input.urlImgName,
 			onlySave,
 		); err != nil {
 
@@ -94,11 +93,378 @@ func pathIsImage(imagePath, urlImgName string, pathIsURl bool, urlImgBytes, pipe
 		}
 	}
 
+	if onlySave {
+		return "", nil
+	}
+
+	if cellTemplate != nil {
+		return executeCellTemplate(asciiSet)
+	}
+
 	ascii := flattenAscii(asciiSet, colored || grayscale, false)
 	result := strings.Join(ascii, "\n")
 
-	if onlySave {
-		return "", nil
+	return wrapForTerminal(result), nil
+}
+
+// Decodes the image from whichever source readInput() retrieved it from
+func decodeImage(imagePath string, input inputData) (image.Image, error) {
+
+	var (
+		imData image.Image
+		err    error
+	)
+
+	if useThumbnail {
+		// Any failure to find or decode the thumbnail falls back to decoding the whole image
+		thumbnail, err := readExifThumbnail(input.readerAt())
+		if err == nil && thumbnailIsLargeEnough(thumbnail) && thumbnailMatchesImage(thumbnail, input.readerAt()) {
+			recordSourceSize(input, thumbnail)
+			return convertColorModel(thumbnail), nil
+		}
+	}
+
+	if streamDecode {
+		imData, err = streamDecodeImage(input)
+		if err == nil {
+			recordSourceSize(input, imData)
+			return convertColorModel(imData), nil
+		} else if err != errStreamUnsupported {
+			return nil, fmt.Errorf("can't decode %v: %v", imagePath, err)
+		}
+
+		// Fall back to decoding the whole image
+		if input.localFile != nil {
+			if _, err := input.localFile.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if imagePath == "-" {
+		imData, _, err = image.Decode(bytes.NewReader(input.pipedInputBytes))
+	} else if input.pathIsURl {
+		imData, _, err = image.Decode(bytes.NewReader(input.urlImgBytes))
+	} else {
+		imData, _, err = image.Decode(input.localFile)
+	}
+	if err != nil && allowPartial {
+		if partialImg, validRows, partialErr := decodeInputPartially(input); partialErr == nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v is truncated, only the first %v of %v rows could be decoded\n", inputName, validRows, partialImg.Bounds().Dy())
+			setSourceSize(partialImg.Bounds().Dx(), partialImg.Bounds().Dy())
+			return convertColorModel(partialImg), nil
+		}
+	}
+	if err != nil {
+		if imagePath == "-" {
+			return nil, fmt.Errorf("can't decode piped input: %v", err)
+		} else {
+			return nil, fmt.Errorf("can't decode %v: %v", imagePath, err)
+		}
+	}
+
+	setSourceSize(imData.Bounds().Dx(), imData.Bounds().Dy())
+	return convertColorModel(imData), nil
+}
+
+/*
+Sets sourceWidth and sourceHeight from the header of the input image, for when decoded is a thumbnail or
+a downsampled version of it. Dimensions of decoded are used if the header can't be read
+*/
+func recordSourceSize(input inputData, decoded image.Image) {
+	config, _, err := image.DecodeConfig(io.NewSectionReader(input.readerAt(), 0, math.MaxInt64))
+	if err != nil {
+		setSourceSize(decoded.Bounds().Dx(), decoded.Bounds().Dy())
+		return
+	}
+	setSourceSize(config.Width, config.Height)
+}
+
+// Sets sourceWidth and sourceHeight, swapped if Flags.TransposeOutput is set so that they match the ascii art
+func setSourceSize(imgWidth, imgHeight int) {
+	sourceWidth, sourceHeight = imgWidth, imgHeight
+	if transposeOutput {
+		sourceWidth, sourceHeight = sourceHeight, sourceWidth
+	}
+}
+
+// Longest side of images shrunk by shrinkForBudget()
+const budgetFallbackSide = 1024
+
+// Quickly shrinks img to at most budgetFallbackSide pixels on its longest side, as described for Flags.TimeBudget
+func shrinkForBudget(img image.Image) image.Image {
+
+	b := img.Bounds()
+	if b.Dx() <= budgetFallbackSide && b.Dy() <= budgetFallbackSide {
+		return img
+	}
+
+	if b.Dx() >= b.Dy() {
+		return imaging.Resize(img, budgetFallbackSide, 0, imaging.NearestNeighbor)
+	}
+	return imaging.Resize(img, 0, budgetFallbackSide, imaging.NearestNeighbor)
+}
+
+// Rereads all of the input data and decodes as much of it as possible through decodePartialImage()
+func decodeInputPartially(input inputData) (image.Image, int, error) {
+
+	var data []byte
+
+	if input.localFile != nil {
+		if _, err := input.localFile.Seek(0, io.SeekStart); err != nil {
+			return nil, 0, err
+		}
+
+		var err error
+		data, err = ioutil.ReadAll(input.localFile)
+		if err != nil {
+			return nil, 0, err
+		}
+	} else if input.pathIsURl {
+		data = input.urlImgBytes
+	} else {
+		data = input.pipedInputBytes
+	}
+
+	return decodePartialImage(data)
+}
+
+// Decodes the input strip by strip through decodeTiffStrips(), if it's a tiff
+func streamDecodeImage(input inputData) (image.Image, error) {
+
+	reader := input.readerAt()
+
+	header := make([]byte, 4)
+	if _, err := reader.ReadAt(header, 0); err != nil || !isTiff(header) {
+		return nil, errStreamUnsupported
+	}
+
+	return decodeTiffStrips(reader)
+}
+
+/*
+Returns true if the thumbnail has enough pixels for the ascii art dimensions set through flags, so
+that using it instead of the full image doesn't lose any detail
+*/
+func thumbnailIsLargeEnough(thumbnail image.Image) bool {
+
+	cellWidth, cellHeight := 1, 1
+	if braille {
+		cellWidth, cellHeight = 2, 4
+	} else if dotMode == "legacy2x2" {
+		cellWidth, cellHeight = 2, 2
+	}
+
+	var neededWidth, neededHeight int
+
+	if len(dimensions) == 2 && !full {
+		neededWidth, neededHeight = dimensions[0], dimensions[1]
+	} else if width != 0 && !full {
+		neededWidth = width
+	} else if height != 0 && !full {
+		neededHeight = height
+	} else {
+		terminalWidth, terminalHeight, err := imgManip.GetTerminalSize([2]int{termWidth, termHeight})
+		if err != nil {
+			return false
+		}
+		neededWidth, neededHeight = terminalWidth, terminalHeight
+	}
+
+	return thumbnail.Bounds().Dx() >= neededWidth*cellWidth && thumbnail.Bounds().Dy() >= neededHeight*cellHeight
+}
+
+// Redraws the image in the color model set by Flags.DecodeColorModel, if any
+func convertColorModel(img image.Image) image.Image {
+
+	var converted draw.Image
+	b := img.Bounds()
+
+	switch decodeModel {
+	case "rgba":
+		if _, ok := img.(*image.RGBA); ok {
+			return img
+		}
+		converted = image.NewRGBA(b)
+	case "nrgba":
+		if _, ok := img.(*image.NRGBA); ok {
+			return img
+		}
+		converted = image.NewNRGBA(b)
+	default:
+		return img
+	}
+
+	draw.Draw(converted, b, img, b.Min, draw.Src)
+	return converted
+}
+
+// Converts decoded image data like convertImageToAsciiSet() for drawing it as an image. With Flags.AspectFromFont,
+// its rows and columns are fitted to the character cells measured from the font, instead of cells twice as tall as they're wide
+func convertImageToSavedAsciiSet(imData image.Image) ([][]imgManip.AsciiChar, error) {
+	if aspectFromFont {
+		cellAspect = fontCellHeight / fontCellWidth
+		defer func() { cellAspect = 0 }()
+	}
+
+	return convertImageToAsciiSet(imData)
+}
+
+// Resizes decoded image data into the pixels that each become part of a character, according to the set flags.
+// If Flags.MaskPath is set, the mask resized to the same pixels is returned as well, otherwise it's nil
+func convertImageToPixelSet(imData image.Image) ([][]imgManip.AsciiPixel, [][]uint8, error) {
+
+	imgSet, err := imgManip.ConvertToAsciiPixels(imData, dimensions, width, height, flipX, flipY, full, braille, dither, imgManip.PixelOptions{
+		AssumeGrayscale:  assumeGrayscale,
+		DotMode:          dotMode,
+		LuminanceChannel: lumaChannel,
+		BlendColor:       blendColor,
+		TermSize:         [2]int{termWidth, termHeight},
+		Filter:           resampleFilter,
+		OrderedDither:    stableNoise,
+		CellAspect:       cellAspect,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if vignette > 0 {
+		imgManip.ApplyVignette(imgSet, vignette)
+	}
+
+	var maskValues [][]uint8
+	if maskImage != nil && len(imgSet) > 0 {
+		maskValues = imgManip.ResizeMask(maskImage, len(imgSet[0]), len(imgSet), flipX, flipY, resampleFilter)
+		imgManip.ApplyMask(imgSet, maskValues)
+	}
+
+	return imgSet, maskValues, nil
+}
+
+// Converts decoded image data into a 2D slice of ascii or braille characters according to the set flags
+func convertImageToAsciiSet(imData image.Image) ([][]imgManip.AsciiChar, error) {
+
+	imgSet, maskValues, err := convertImageToPixelSet(imData)
+	if err != nil {
+		return nil, err
+	}
+
+	dotThreshold := threshold
+	if adaptive && (braille || dotMode != "") {
+		// Pixels are binarized to 0 or 255, so any threshold in between separates them
+		imgManip.ApplyAdaptiveThreshold(imgSet, adaptiveWindow)
+		dotThreshold = 128
 	}
-	return result, nil
+
+	var asciiSet [][]imgManip.AsciiChar
+
+	if separated && braille {
+		asciiSet, err = imgManip.ConvertToSeparatedDotChars(imgSet, 2, 4, negative, colorBg, channelLimits)
+	} else if separated {
+		asciiSet, err = imgManip.ConvertToSeparatedDotChars(imgSet, 2, 2, negative, colorBg, channelLimits)
+	} else if brailleFull {
+		asciiSet, err = imgManip.ConvertToBrailleColorFullChars(imgSet, negative, grayscale, dotThreshold)
+	} else if braille {
+		asciiSet, err = imgManip.ConvertToBrailleChars(imgSet, negative, colored, grayscale, colorBg, fontColor, dotThreshold)
+	} else if dotMode == "legacy2x2" {
+		asciiSet, err = imgManip.ConvertToQuadrantChars(imgSet, negative, colored, grayscale, colorBg, fontColor)
+	} else {
+		charMap := customMap
+		if charMap == "" && rampPreset != "" {
+			charMap = rampPresets[rampPreset]
+		}
+		asciiSet, err = imgManip.ConvertToAsciiChars(imgSet, negative, colored, grayscale, complex, colorBg, charMap, fontColor, imgManip.CharOptions{
+			MaxGlyphs:     maxGlyphs,
+			DetailOverlay: detailOverlay,
+			EdgeThreshold: edgeThreshold,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if maskValues != nil {
+		hideMaskedChars(asciiSet, maskValues)
+	}
+
+	if transposeOutput {
+		asciiSet = transposeAsciiSet(asciiSet)
+	}
+
+	if widthMultiple > 1 || heightMultiple > 1 {
+		asciiSet = padToMultiples(asciiSet)
+	}
+
+	return asciiSet, nil
+}
+
+// Sets convertedCols and convertedRows to the dimensions of asciiSet, for ConvertSized()
+func recordConvertedSize(asciiSet [][]imgManip.AsciiChar) {
+	convertedRows = len(asciiSet)
+	if convertedRows > 0 {
+		convertedCols = len(asciiSet[0])
+	}
+}
+
+// Replaces characters with blank spaces wherever the mask of Flags.MaskPath is black. maskValues holds the mask
+// resized to the pixels of the image, so braille and dot characters are hidden only if all of their pixels are
+func hideMaskedChars(asciiSet [][]imgManip.AsciiChar, maskValues [][]uint8) {
+
+	if len(asciiSet) == 0 || len(asciiSet[0]) == 0 {
+		return
+	}
+
+	cellWidth := len(maskValues[0]) / len(asciiSet[0])
+	cellHeight := len(maskValues) / len(asciiSet)
+
+	for y := range asciiSet {
+		for x := range asciiSet[y] {
+			if cellMasked(maskValues, x*cellWidth, y*cellHeight, cellWidth, cellHeight) {
+				asciiSet[y][x] = blankChar
+			}
+		}
+	}
+}
+
+// Returns true if every mask value in the cellWidth x cellHeight cell starting at column x and row y is black
+func cellMasked(maskValues [][]uint8, x, y, cellWidth, cellHeight int) bool {
+	for cy := y; cy < y+cellHeight; cy++ {
+		for cx := x; cx < x+cellWidth; cx++ {
+			if maskValues[cy][cx] != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Pads ascii art with blank characters on its right and bottom, until its columns are a multiple
+// of Flags.WidthMultiple and its rows are a multiple of Flags.HeightMultiple
+func padToMultiples(asciiSet [][]imgManip.AsciiChar) [][]imgManip.AsciiChar {
+
+	if len(asciiSet) == 0 {
+		return asciiSet
+	}
+
+	cols := len(asciiSet[0])
+	rows := len(asciiSet)
+
+	if widthMultiple > 1 && cols%widthMultiple != 0 {
+		cols += widthMultiple - cols%widthMultiple
+	}
+	if heightMultiple > 1 && rows%heightMultiple != 0 {
+		rows += heightMultiple - rows%heightMultiple
+	}
+
+	for len(asciiSet) < rows {
+		asciiSet = append(asciiSet, nil)
+	}
+
+	for y := range asciiSet {
+		for len(asciiSet[y]) < cols {
+			asciiSet[y] = append(asciiSet[y], blankChar)
+		}
+	}
+
+	return asciiSet
 }
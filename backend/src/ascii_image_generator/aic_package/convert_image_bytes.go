@@ -0,0 +1,72 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"encoding/base64"
+)
+
+/*
+ConvertToImageBytes() takes the same arguments as Convert(), but instead of returning the
+ascii art string, it returns the ascii art rendered as a .png image, in the same way
+Flags.SaveImagePath would save it, including Flags.OutputDPI and Flags.EmbedMetadata.
+Nothing is printed or saved.
+
+Gifs are treated as still images and only their first frame is rendered.
+*/
+func ConvertToImageBytes(filePath string, flags Flags) ([]byte, error) {
+
+	if err := setFlags(flags); err != nil {
+		return nil, err
+	}
+
+	input, err := readInput(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer input.close()
+
+	if err := loadFont(); err != nil {
+		return nil, err
+	}
+
+	imData, err := decodeImage(filePath, input)
+	if err != nil {
+		return nil, err
+	}
+
+	asciiSet, err := convertImageToSavedAsciiSet(imData)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeAsciiPng(asciiSet, colored || grayscale, filePath)
+}
+
+/*
+ConvertToImageDataURI() works like ConvertToImageBytes(), but returns the rendered .png image as a
+base64 encoded data URI, e.g. "data:image/png;base64,...", which can be used directly in an <img src>
+*/
+func ConvertToImageDataURI(filePath string, flags Flags) (string, error) {
+
+	imgBytes, err := ConvertToImageBytes(filePath, flags)
+	if err != nil {
+		return "", err
+	}
+
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(imgBytes), nil
+}
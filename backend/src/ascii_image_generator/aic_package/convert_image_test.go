@@ -0,0 +1,203 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBinaryPreserveCut(t *testing.T) {
+	// Gray levels on both sides of the cut, one pixel per character
+	levels := []uint8{0, 1, 126, 127, 128, 129, 254, 255}
+	img := image.NewGray(image.Rect(0, 0, len(levels), 1))
+	for x, level := range levels {
+		img.SetGray(x, 0, color.Gray{level})
+	}
+
+	imagePath, cleanup := writeTestPng(t, img)
+	defer cleanup()
+
+	flags := DefaultFlags()
+	flags.Dimensions = []int{len(levels), 1}
+	flags.BinaryPreserve = true
+
+	got, err := Convert(imagePath, flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "    ████"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBinaryPreserveMajority(t *testing.T) {
+	// 2x2 modules with 1 and 3 of their 4 pixels bright, each becoming a single character
+	img := image.NewGray(image.Rect(0, 0, 4, 2))
+	for _, pt := range []image.Point{{0, 0}, {2, 0}, {3, 0}, {3, 1}} {
+		img.SetGray(pt.X, pt.Y, color.Gray{255})
+	}
+
+	imagePath, cleanup := writeTestPng(t, img)
+	defer cleanup()
+
+	flags := DefaultFlags()
+	flags.Dimensions = []int{2, 1}
+	flags.BinaryPreserve = true
+
+	got, err := Convert(imagePath, flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := " █"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestChannelThresholdLimits(t *testing.T) {
+	tests := []struct {
+		thresholds [3]int
+		valid      bool
+	}{
+		{[3]int{0, 0, 0}, true},
+		{[3]int{255, 255, 255}, true},
+		{[3]int{0, 128, 255}, true},
+		{[3]int{-1, 128, 128}, false},
+		{[3]int{128, 128, 256}, false},
+	}
+
+	for _, test := range tests {
+		flags := DefaultFlags()
+		flags.Braille = true
+		flags.ChannelSeparated = true
+		flags.ChannelThresholds = test.thresholds
+
+		if err := setFlags(flags); (err == nil) != test.valid {
+			t.Errorf("thresholds %v: got error %v, want valid %v", test.thresholds, err, test.valid)
+		}
+	}
+}
+
+func TestMaskResampling(t *testing.T) {
+	whiteImage := func(width, height int) image.Image {
+		img := image.NewGray(image.Rect(0, 0, width, height))
+		for i := range img.Pix {
+			img.Pix[i] = 255
+		}
+		return img
+	}
+
+	// Mask columns are white where the string has a 1 and black where it has a 0
+	maskImage := func(columns string, height int) image.Image {
+		img := image.NewGray(image.Rect(0, 0, len(columns), height))
+		for y := 0; y < height; y++ {
+			for x := range columns {
+				if columns[x] == '1' {
+					img.SetGray(x, y, color.Gray{255})
+				}
+			}
+		}
+		return img
+	}
+
+	tests := []struct {
+		name       string
+		img        image.Image
+		mask       image.Image
+		dimensions []int
+		braille    bool
+		resampling string
+		want       string
+	}{
+		// The first character covers a white and a black mask column
+		{"nearest", whiteImage(8, 2), maskImage("10001111", 2), []int{4, 1}, false, "nearest", "  @@"},
+		{"box", whiteImage(8, 2), maskImage("10001111", 2), []int{4, 1}, false, "box", "+ @@"},
+
+		// Braille characters are hidden only if the mask is black over all of their dots
+		{"braille", whiteImage(4, 4), maskImage("0100", 4), []int{2, 1}, true, "nearest", "⢸ "},
+	}
+
+	for _, test := range tests {
+		imagePath, cleanup := writeTestPng(t, test.img)
+		defer cleanup()
+		maskPath, maskCleanup := writeTestPng(t, test.mask)
+		defer maskCleanup()
+
+		flags := DefaultFlags()
+		flags.Dimensions = test.dimensions
+		flags.Braille = test.braille
+		flags.Resampling = test.resampling
+		flags.MaskPath = maskPath
+
+		got, err := Convert(imagePath, flags)
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		if got != test.want {
+			t.Errorf("%v: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestAspectFromFontSavedImage(t *testing.T) {
+	// A square image, so saved ascii art should come out square as well
+	imagePath, cleanup := writeTestPng(t, image.NewGray(image.Rect(0, 0, 10, 10)))
+	defer cleanup()
+
+	flags := DefaultFlags()
+	flags.Width = 200
+	flags.AspectFromFont = true
+	flags.SaveImagePath = filepath.Dir(imagePath)
+
+	ascii, err := Convert(imagePath, flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Terminal output keeps characters twice as tall as they're wide
+	if rows := len(strings.Split(ascii, "\n")); rows != 100 {
+		t.Errorf("got %v rows for the terminal, want 100", rows)
+	}
+
+	savedFile, err := os.Open(filepath.Join(flags.SaveImagePath, "test-ascii-art.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer savedFile.Close()
+
+	config, err := png.DecodeConfig(savedFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 5 pixels of padding on each side
+	artWidth, artHeight := float64(config.Width-10), float64(config.Height-10)
+	if ratio := artHeight / artWidth; math.Abs(ratio-1) > 0.01 {
+		t.Errorf("saved image is %vx%v without padding, want it square", artWidth, artHeight)
+	}
+
+	wantRows := int(200 / (fontCellHeight / fontCellWidth))
+	if rows := int(math.Round(artHeight / fontCellHeight)); rows != wantRows {
+		t.Errorf("got %v rows for the saved image, want %v", rows, wantRows)
+	}
+}
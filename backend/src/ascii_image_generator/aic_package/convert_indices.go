@@ -0,0 +1,107 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	imgManip "github.com/TheZoraiz/ascii-image-converter/image_manipulation"
+)
+
+/*
+ConvertIndices() takes the same arguments as Convert(), but instead of the ascii art string,
+it returns the index of each character in the chosen character set, 0 being the darkest.
+If Flags.Braille is set, each index is the bitmask of the braille character's highlighted dots.
+If Flags.DotMode is set, indexes are as described for image_manipulation.ConvertToQuadrantChars().
+
+Gifs are treated as still images and only their first frame is converted. Use ConvertGifIndices() for
+all frames. Nothing is printed or saved.
+*/
+func ConvertIndices(filePath string, flags Flags) ([][]int, error) {
+
+	if err := setFlags(flags); err != nil {
+		return nil, err
+	}
+
+	input, err := readInput(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer input.close()
+
+	imData, err := decodeImage(filePath, input)
+	if err != nil {
+		return nil, err
+	}
+
+	asciiSet, err := convertImageToAsciiSet(imData)
+	if err != nil {
+		return nil, err
+	}
+
+	return asciiSetToIndices(asciiSet), nil
+}
+
+/*
+ConvertGifIndices() works like ConvertIndices(), but takes a gif path/url and returns the
+character indices for each of its frames
+*/
+func ConvertGifIndices(filePath string, flags Flags) ([][][]int, error) {
+
+	if err := setFlags(flags); err != nil {
+		return nil, err
+	}
+
+	input, err := readInput(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer input.close()
+
+	originalGif, err := decodeGif(filePath, input)
+	if err != nil {
+		return nil, err
+	}
+
+	compositedFrames, _, err := clipGifFrames(compositeGifFrames(originalGif), originalGif.Delay)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([][][]int, len(compositedFrames))
+
+	for i, frame := range compositedFrames {
+		asciiSet, err := convertImageToAsciiSet(convertColorModel(frame))
+		if err != nil {
+			return nil, err
+		}
+		frames[i] = asciiSetToIndices(asciiSet)
+	}
+
+	return frames, nil
+}
+
+func asciiSetToIndices(asciiSet [][]imgManip.AsciiChar) [][]int {
+	indices := make([][]int, len(asciiSet))
+
+	for i, line := range asciiSet {
+		indices[i] = make([]int, len(line))
+		for j, char := range line {
+			indices[i][j] = char.Index
+		}
+	}
+
+	return indices
+}
@@ -0,0 +1,127 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"fmt"
+	"strings"
+
+	imgManip "github.com/TheZoraiz/ascii-image-converter/image_manipulation"
+)
+
+type OutputTarget struct {
+	// Key of this target in the map returned by ConvertMulti(). Must be unique among targets
+	Name string
+
+	// Format written to Path. Accepts "txt" for plain ascii art without colors, "ansi" for
+	// ascii art with terminal color codes as returned by Convert(), or "png" for the ascii
+	// art rendered as an image like Flags.SaveImagePath
+	Format string
+
+	// Path of the file to write, including its name. Existing files are overwritten
+	Path string
+}
+
+/*
+ConvertMulti() takes an image path/url, a aic_package.Flags literal and a slice of aic_package.OutputTarget.
+The image is converted once, and the resulting ascii art is written to every target. The returned map holds
+an entry for each target name, which is nil if the target was written successfully. The returned error is
+only set if the image couldn't be converted at all, in which case no target is written.
+
+Gifs are treated as still images and only their first frame is converted. Saving flags are ignored and
+nothing is printed.
+*/
+func ConvertMulti(filePath string, flags Flags, targets []OutputTarget) (map[string]error, error) {
+
+	names := map[string]bool{}
+	for _, target := range targets {
+		if names[target.Name] {
+			return nil, fmt.Errorf("duplicate output target name %v", target.Name)
+		}
+		names[target.Name] = true
+	}
+
+	if err := setFlags(flags); err != nil {
+		return nil, err
+	}
+
+	input, err := readInput(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer input.close()
+
+	if err := loadFont(); err != nil {
+		return nil, err
+	}
+
+	imData, err := decodeImage(filePath, input)
+	if err != nil {
+		return nil, err
+	}
+
+	asciiSet, err := convertImageToAsciiSet(imData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Png targets are drawn from ascii art fitted to the font's cells, as described for Flags.AspectFromFont
+	var imageSet [][]imgManip.AsciiChar
+
+	results := make(map[string]error, len(targets))
+	for _, target := range targets {
+		targetSet := asciiSet
+		if target.Format == "png" && aspectFromFont && len(dimensions) == 0 {
+			if imageSet == nil {
+				if imageSet, err = convertImageToSavedAsciiSet(imData); err != nil {
+					return nil, err
+				}
+			}
+			targetSet = imageSet
+		}
+
+		results[target.Name] = writeOutputTarget(targetSet, target, filePath)
+	}
+
+	return results, nil
+}
+
+// Writes asciiSet, converted from imagePath, to target.Path in target.Format
+func writeOutputTarget(asciiSet [][]imgManip.AsciiChar, target OutputTarget, imagePath string) error {
+
+	var data []byte
+
+	switch target.Format {
+	case "txt":
+		data = []byte(strings.Join(flattenAscii(asciiSet, false, true), "\n"))
+	case "ansi":
+		data = []byte(strings.Join(flattenAscii(asciiSet, colored || grayscale, false), "\n"))
+	case "png":
+		var err error
+		if data, err = encodeAsciiPng(asciiSet, colored || grayscale, imagePath); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid output format %v, must be one of txt, ansi or png", target.Format)
+	}
+
+	if err := writeSaveFile(target.Path, data); err != nil {
+		return fmt.Errorf("can't save file: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"fmt"
+	"image"
+	"strings"
+)
+
+/*
+ConvertPixels() converts raw pixel data that's already in memory, without any decoding. Each row of the image
+starts stride bytes after the previous one in pix, so rows don't need to be tightly packed. The pixels are
+used directly without being copied, so pix must not be modified until ConvertPixels() returns.
+
+model decides the layout of each pixel. It accepts "rgba" for 4 bytes per pixel with colors premultiplied
+by alpha, "nrgba" for 4 bytes per pixel with colors not premultiplied by alpha, or "gray" for 1 byte per pixel.
+
+len(pix) must be at least stride*(height-1) plus the bytes in a row of width pixels. Flags.DecodeColorModel
+and saving flags are ignored.
+*/
+func ConvertPixels(pix []uint8, width, height, stride int, model string, flags Flags) (string, error) {
+
+	var bytesPerPixel int
+	switch model {
+	case "rgba", "nrgba":
+		bytesPerPixel = 4
+	case "gray":
+		bytesPerPixel = 1
+	default:
+		return "", fmt.Errorf("invalid pixel model %v, must be one of rgba, nrgba or gray", model)
+	}
+
+	if width < 1 || height < 1 {
+		return "", fmt.Errorf("pixel data dimensions must be at least 1x1, got %vx%v", width, height)
+	}
+	if stride < width*bytesPerPixel {
+		return "", fmt.Errorf("stride %v is shorter than a row of %v pixels", stride, width)
+	}
+	if neededLength := stride*(height-1) + width*bytesPerPixel; len(pix) < neededLength {
+		return "", fmt.Errorf("pixel data has %v bytes, but %vx%v pixels with stride %v need %v", len(pix), width, height, stride, neededLength)
+	}
+
+	if err := setFlags(flags); err != nil {
+		return "", err
+	}
+
+	if err := loadFont(); err != nil {
+		return "", err
+	}
+
+	inputName = "pixel data"
+	inputIsGif = false
+
+	bounds := image.Rect(0, 0, width, height)
+
+	var imData image.Image
+	switch model {
+	case "rgba":
+		imData = &image.RGBA{Pix: pix, Stride: stride, Rect: bounds}
+	case "nrgba":
+		imData = &image.NRGBA{Pix: pix, Stride: stride, Rect: bounds}
+	case "gray":
+		imData = &image.Gray{Pix: pix, Stride: stride, Rect: bounds}
+	}
+
+	asciiSet, err := convertImageToAsciiSet(imData)
+	if err != nil {
+		return "", err
+	}
+
+	if cellTemplate != nil {
+		return executeCellTemplate(asciiSet)
+	}
+
+	ascii := flattenAscii(asciiSet, colored || grayscale, false)
+
+	return wrapForTerminal(strings.Join(ascii, "\n")), nil
+}
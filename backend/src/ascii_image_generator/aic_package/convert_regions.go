@@ -0,0 +1,136 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	imgManip "github.com/TheZoraiz/ascii-image-converter/image_manipulation"
+)
+
+type RegionSpec struct {
+	// Rectangle of ascii art characters this region covers, in output character coordinates.
+	// e.g. image.Rect(0, 0, 40, 30) covers the first 40 columns of the first 30 rows
+	Rect image.Rectangle
+
+	// Flags used for characters inside Rect. Flags.Dimensions, Flags.Width, Flags.Height,
+	// Flags.Full and Flags.TransposeOutput are ignored, since each region must match the
+	// base ascii art dimensions. Flags that shape the output as a whole, such as Flags.Indent,
+	// Flags.WrapAt, Flags.CellTemplate and Flags.SingleLineEscaped, are taken from the base flags
+	Flags Flags
+}
+
+/*
+ConvertRegions() takes an image path/url, a slice of aic_package.RegionSpec and a aic_package.Flags literal.
+The whole image is first converted with the passed flags, then each region is converted again with its own flags
+at the same dimensions, and its characters are placed over the base ascii art. Later regions take precedence
+over earlier ones where they overlap.
+
+Gifs are treated as still images and only their first frame is converted. Saving flags, along with
+Flags.WidthMultiple and Flags.HeightMultiple, are ignored.
+*/
+func ConvertRegions(filePath string, regions []RegionSpec, flags Flags) (string, error) {
+
+	// Padding would make region dimensions differ from the ones the base image was resized to
+	flags.WidthMultiple = 0
+	flags.HeightMultiple = 0
+
+	if err := setFlags(flags); err != nil {
+		return "", err
+	}
+
+	input, err := readInput(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer input.close()
+
+	imData, err := decodeImage(filePath, input)
+	if err != nil {
+		return "", err
+	}
+
+	baseSet, err := convertImageToAsciiSet(imData)
+	if err != nil {
+		return "", err
+	}
+
+	rows := len(baseSet)
+	cols := len(baseSet[0])
+	bounds := image.Rect(0, 0, cols, rows)
+
+	// Composited grid of each character's display string, which keeps the colors of the flags
+	// it was converted with, along with the characters themselves for Flags.CellTemplate
+	grid := make([][]string, rows)
+	chars := make([][]imgManip.AsciiChar, rows)
+	for y, line := range baseSet {
+		grid[y] = make([]string, cols)
+		chars[y] = append([]imgManip.AsciiChar(nil), line...)
+		for x, char := range line {
+			grid[y][x] = flattenChar(char, colored || grayscale, false)
+		}
+	}
+
+	for i, region := range regions {
+
+		if region.Rect.Empty() || !region.Rect.In(bounds) {
+			return "", fmt.Errorf("region %v with rectangle %v doesn't fit ascii art dimensions %vx%v", i, region.Rect, cols, rows)
+		}
+
+		regionFlags := region.Flags
+		regionFlags.Dimensions = []int{cols, rows}
+		regionFlags.TransposeOutput = flags.TransposeOutput
+		if flags.TransposeOutput {
+			// Dimensions apply before transposing
+			regionFlags.Dimensions = []int{rows, cols}
+		}
+		regionFlags.Width = 0
+		regionFlags.Height = 0
+		regionFlags.Full = false
+		regionFlags.WidthMultiple = 0
+		regionFlags.HeightMultiple = 0
+
+		if err := setFlags(regionFlags); err != nil {
+			return "", fmt.Errorf("region %v: %v", i, err)
+		}
+
+		regionSet, err := convertImageToAsciiSet(imData)
+		if err != nil {
+			return "", fmt.Errorf("region %v: %v", i, err)
+		}
+
+		for y := region.Rect.Min.Y; y < region.Rect.Max.Y; y++ {
+			for x := region.Rect.Min.X; x < region.Rect.Max.X; x++ {
+				grid[y][x] = flattenChar(regionSet[y][x], colored || grayscale, false)
+				chars[y][x] = regionSet[y][x]
+			}
+		}
+	}
+
+	// Output flags like Flags.WrapAt and Flags.SingleLineEscaped come from the base flags
+	if err := setFlags(flags); err != nil {
+		return "", err
+	}
+
+	if cellTemplate != nil {
+		return executeCellTemplate(chars)
+	}
+
+	return wrapForTerminal(strings.Join(flattenCells(grid), "\n")), nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"image"
+	"image/draw"
+	"testing"
+)
+
+func TestConvertRegionsOutputFlags(t *testing.T) {
+	white := image.NewNRGBA(image.Rect(0, 0, 8, 4))
+	draw.Draw(white, white.Bounds(), image.White, image.Point{}, draw.Src)
+
+	imagePath, cleanup := writeTestPng(t, white)
+	defer cleanup()
+
+	// The region turns its white pixels into the darkest character, a space
+	regionFlags := DefaultFlags()
+	regionFlags.Negative = true
+	regions := []RegionSpec{{Rect: image.Rect(0, 0, 2, 1), Flags: regionFlags}}
+
+	tests := []struct {
+		name  string
+		setup func(flags *Flags)
+		want  string
+	}{
+		{"plain", func(flags *Flags) {}, "  @@@@@@\n@@@@@@@@\n@@@@@@@@\n@@@@@@@@"},
+		{"wrapped", func(flags *Flags) { flags.WrapAt = 4 }, "  @@\n@@@@\n@@@@\n@@@@\n@@@@\n@@@@\n@@@@\n@@@@"},
+		{"single line", func(flags *Flags) { flags.SingleLineEscaped = true }, `  @@@@@@\n@@@@@@@@\n@@@@@@@@\n@@@@@@@@`},
+		{"cell template", func(flags *Flags) {
+			flags.CellTemplate = "{{.Char}}"
+			flags.RowSeparator = "|"
+		}, "  @@@@@@|@@@@@@@@|@@@@@@@@|@@@@@@@@"},
+	}
+
+	for _, test := range tests {
+		flags := DefaultFlags()
+		flags.Dimensions = []int{8, 4}
+		test.setup(&flags)
+
+		got, err := ConvertRegions(imagePath, regions, flags)
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		if got != test.want {
+			t.Errorf("%v: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -17,11 +17,17 @@ limitations under the License.
 package aic_package
 
 import (
+	"bytes"
 	"fmt"
+	"image"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"path"
+	"path/filepath"
+	"text/template"
+	"time"
 
 	// Image format initialization
 	_ "image/jpeg"
@@ -32,6 +38,7 @@ import (
 	_ "golang.org/x/image/tiff"
 	_ "golang.org/x/image/webp"
 
+	imgManip "github.com/TheZoraiz/ascii-image-converter/image_manipulation"
 	"github.com/golang/freetype/truetype"
 )
 
@@ -43,32 +50,104 @@ var pipedInputTypes = []string{
 	"image/bmp",
 }
 
+// Returned, wrapped with the image's dimensions, when a decoded image or gif frame has no pixels, such as
+// malformed files reporting 0x0 dimensions. Check for it with errors.Is()
+var ErrInvalidImage = imgManip.ErrInvalidImage
+
 // Return default configuration for flags.
 // Can be sent directly to ConvertImage() for default ascii art
 func DefaultFlags() Flags {
 	return Flags{
-		Complex:             false,
-		Dimensions:          nil,
-		Width:               0,
-		Height:              0,
-		SaveTxtPath:         "",
-		SaveImagePath:       "",
-		SaveGifPath:         "",
-		Negative:            false,
-		Colored:             false,
-		CharBackgroundColor: false,
-		Grayscale:           false,
-		CustomMap:           "",
-		FlipX:               false,
-		FlipY:               false,
-		Full:                false,
-		FontFilePath:        "",
-		FontColor:           [3]int{255, 255, 255},
-		SaveBackgroundColor: [4]int{0, 0, 0, 100},
-		Braille:             false,
-		Threshold:           128,
-		Dither:              false,
-		OnlySave:            false,
+		Complex:              false,
+		Dimensions:           nil,
+		Width:                0,
+		Height:               0,
+		SaveTxtPath:          "",
+		SaveImagePath:        "",
+		SaveGifPath:          "",
+		Negative:             false,
+		Colored:              false,
+		CharBackgroundColor:  false,
+		Grayscale:            false,
+		CustomMap:            "",
+		FlipX:                false,
+		FlipY:                false,
+		Full:                 false,
+		FontFilePath:         "",
+		FontColor:            [3]int{255, 255, 255},
+		SaveBackgroundColor:  [4]int{0, 0, 0, 100},
+		Braille:              false,
+		Threshold:            128,
+		Dither:               false,
+		OnlySave:             false,
+		AssumeGrayscale:      false,
+		CursorSaveRestore:    false,
+		DecodeColorModel:     "nrgba",
+		MaxGlyphs:            0,
+		TargetFPS:            0,
+		EmbedMetadata:        false,
+		StreamDecode:         false,
+		DotMode:              "",
+		TransposeOutput:      false,
+		MinFrameDelay:        0,
+		RampPreset:           "",
+		CellTemplate:         "",
+		RowSeparator:         "",
+		UseEmbeddedThumbnail: false,
+		SaveAnsiMoviePath:    "",
+		LuminanceChannel:     "luma",
+		StartTime:            0,
+		Duration:             0,
+		Indent:               "",
+		ForceStatic:          false,
+		AspectFromFont:       false,
+		Vignette:             0,
+		SetTerminalTitle:     false,
+		MaskPath:             "",
+		WidthMultiple:        1,
+		HeightMultiple:       1,
+		BlendAlphaAgainst:    [3]int{0, 0, 0},
+		LinePrefix:           "",
+		LineSuffix:           "",
+		DetailOverlay:        false,
+		EdgeThreshold:        96,
+		WrapAt:               0,
+		ScrollAnimation: ScrollAnimation{
+			Direction:      "right",
+			Frames:         0,
+			ViewportWidth:  0,
+			ViewportHeight: 0,
+			FrameDelay:     10,
+		},
+		AdaptiveThreshold:       false,
+		AdaptiveWindow:          15,
+		AllowPartialDecode:      false,
+		AutoInvertForBackground: false,
+		TerminalBackground:      [3]int{-1, -1, -1},
+		OutputDPI:               0,
+		PaperSize:               "",
+		TimeBudget:              0,
+		Mosaic:                  false,
+		PremultipliedOutput:     false,
+		TermWidth:               0,
+		TermHeight:              0,
+		Resampling:              "lanczos",
+		SingleLineEscaped:       false,
+		BlinkBrightest:          false,
+		ConcealDarkest:          false,
+		MatchSourceResolution:   false,
+		BinaryPreserve:          false,
+		DropShadow:              false,
+		ShadowColor:             [4]int{0, 0, 0, 60},
+		ShadowOffset:            [2]int{4, 4},
+		ChannelSeparated:        false,
+		ChannelThresholds:       [3]int{128, 128, 128},
+		Color16:                 false,
+		Theme16:                 xtermTheme16,
+		BrailleColorFull:        false,
+		AtomicWrites:            true,
+		ColorTieBreak:           "lowest",
+		StableNoise:             false,
 	}
 }
 
@@ -79,73 +158,122 @@ the returned ascii art string.
 */
 func Convert(filePath string, flags Flags) (string, error) {
 
-	if flags.Dimensions == nil {
-		dimensions = nil
+	if err := setFlags(flags); err != nil {
+		return "", err
+	}
+
+	input, err := readInput(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer input.close()
+
+	if err := loadFont(); err != nil {
+		return "", err
+	}
+
+	if inputIsGif && !forceStatic {
+		return "", pathIsGif(filePath, input)
+	} else if scroll.Frames > 0 {
+		return "", pathIsScroll(filePath, input)
 	} else {
-		dimensions = flags.Dimensions
+		return pathIsImage(filePath, input)
 	}
-	width = flags.Width
-	height = flags.Height
-	complex = flags.Complex
-	saveTxtPath = flags.SaveTxtPath
-	saveImagePath = flags.SaveImagePath
-	saveGifPath = flags.SaveGifPath
-	negative = flags.Negative
-	colored = flags.Colored
-	colorBg = flags.CharBackgroundColor
-	grayscale = flags.Grayscale
-	customMap = flags.CustomMap
-	flipX = flags.FlipX
-	flipY = flags.FlipY
-	full = flags.Full
-	fontPath = flags.FontFilePath
-	fontColor = flags.FontColor
-	saveBgColor = flags.SaveBackgroundColor
-	braille = flags.Braille
-	threshold = flags.Threshold
-	dither = flags.Dither
-	onlySave = flags.OnlySave
+}
+
+/*
+ConvertSized() works like Convert(), but also returns the number of columns and rows of the ascii art,
+as the final dimensions may differ from the requested ones because of rounding and aspect ratio.
+Color codes and Flags.Indent aren't counted. For gifs, the dimensions of their frames are returned
+along with an empty string, like Convert()
+*/
+func ConvertSized(filePath string, flags Flags) (string, int, int, error) {
+
+	convertedCols, convertedRows = 0, 0
+
+	asciiArt, err := Convert(filePath, flags)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	return asciiArt, convertedCols, convertedRows, nil
+}
+
+// Holds the raw data of an input, depending upon whether it's a local file, a url or piped stdin
+type inputData struct {
+	localFile       *os.File
+	urlImgBytes     []byte
+	urlImgName      string
+	pipedInputBytes []byte
+	pathIsURl       bool
+}
+
+// Returns a reader for random access to the input data
+func (input inputData) readerAt() io.ReaderAt {
+	if input.localFile != nil {
+		return input.localFile
+	} else if input.pathIsURl {
+		return bytes.NewReader(input.urlImgBytes)
+	}
+	return bytes.NewReader(input.pipedInputBytes)
+}
+
+// Closes the local file, if one was opened
+func (input inputData) close() {
+	if input.localFile != nil {
+		input.localFile.Close()
+	}
+}
+
+/*
+This function reads data from filePath according to whether it's a url, a local file
+or "-" for piped stdin. It also sets inputIsGif if piped input is detected to be a gif, and
+inputName for Flags.SetTerminalTitle.
+
+The caller is responsible for calling close() on the returned inputData
+*/
+func readInput(filePath string) (inputData, error) {
 
 	inputIsGif = path.Ext(filePath) == ".gif"
 
-	// Declared at the start since some variables are initially used in conditional blocks
+	inputName = filepath.Base(filePath)
+	if filePath == "-" {
+		inputName = "piped input"
+	}
+
 	var (
-		localFile       *os.File
-		urlImgBytes     []byte
-		urlImgName      string = ""
-		pipedInputBytes []byte
-		err             error
+		input inputData
+		err   error
 	)
 
-	pathIsURl := isURL(filePath)
+	input.pathIsURl = isURL(filePath)
 
 	// Different modes of reading data depending upon whether or not filePath is a url
 
 	if filePath != "-" {
-		if pathIsURl {
+		if input.pathIsURl {
 			fmt.Printf("Fetching file from url...\r")
 
 			retrievedImage, err := http.Get(filePath)
 			if err != nil {
-				return "", fmt.Errorf("can't fetch content: %v", err)
+				return input, fmt.Errorf("can't fetch content: %v", err)
 			}
 
-			urlImgBytes, err = ioutil.ReadAll(retrievedImage.Body)
+			input.urlImgBytes, err = ioutil.ReadAll(retrievedImage.Body)
+			retrievedImage.Body.Close()
 			if err != nil {
-				return "", fmt.Errorf("failed to read fetched content: %v", err)
+				return input, fmt.Errorf("failed to read fetched content: %v", err)
 			}
-			defer retrievedImage.Body.Close()
 
-			urlImgName = path.Base(filePath)
+			input.urlImgName = path.Base(filePath)
 			fmt.Printf("                          \r") // To erase "Fetching image from url..." text from terminal
 
 		} else {
 
-			localFile, err = os.Open(filePath)
+			input.localFile, err = os.Open(filePath)
 			if err != nil {
-				return "", fmt.Errorf("unable to open file: %v", err)
+				return input, fmt.Errorf("unable to open file: %v", err)
 			}
-			defer localFile.Close()
 
 		}
 
@@ -153,15 +281,15 @@ func Convert(filePath string, flags Flags) (string, error) {
 		// Check file/data type of piped input
 
 		if !isInputFromPipe() {
-			return "", fmt.Errorf("there is no input being piped to stdin")
+			return input, fmt.Errorf("there is no input being piped to stdin")
 		}
 
-		pipedInputBytes, err = ioutil.ReadAll(os.Stdin)
+		input.pipedInputBytes, err = ioutil.ReadAll(os.Stdin)
 		if err != nil {
-			return "", fmt.Errorf("unable to read piped input: %v", err)
+			return input, fmt.Errorf("unable to read piped input: %v", err)
 		}
 
-		fileType := http.DetectContentType(pipedInputBytes)
+		fileType := http.DetectContentType(input.pipedInputBytes)
 		invalidInput := true
 
 		if fileType == "image/gif" {
@@ -184,24 +312,378 @@ func Convert(filePath string, flags Flags) (string, error) {
 		}
 	}
 
-	// If path to font file is provided, use it
+	return input, nil
+}
+
+// Checks the passed flags for invalid combinations and copies them into the package
+// level variables used throughout the conversion
+func setFlags(flags Flags) error {
+
+	if flags.AssumeGrayscale && flags.Colored {
+		return fmt.Errorf("assume grayscale can't be used along with colored")
+	}
+
+	if flags.DecodeColorModel != "" && flags.DecodeColorModel != "rgba" && flags.DecodeColorModel != "nrgba" {
+		return fmt.Errorf("invalid decode color model %v, must be either rgba or nrgba", flags.DecodeColorModel)
+	}
+
+	if flags.MaxGlyphs < 0 || flags.MaxGlyphs == 1 {
+		return fmt.Errorf("max glyphs must be either 0 or at least 2")
+	}
+
+	if flags.DotMode != "" && flags.DotMode != "legacy2x2" {
+		return fmt.Errorf("invalid dot mode %v, only legacy2x2 is supported", flags.DotMode)
+	}
+
+	if flags.DotMode != "" && flags.Braille {
+		return fmt.Errorf("dot mode can't be used along with braille")
+	}
+
+	if _, ok := rampPresets[flags.RampPreset]; flags.RampPreset != "" && !ok {
+		return fmt.Errorf("invalid ramp preset %v, only shade is supported", flags.RampPreset)
+	}
+
+	cellTemplate = nil
+	if flags.CellTemplate != "" {
+		var err error
+		if cellTemplate, err = template.New("cell").Parse(flags.CellTemplate); err != nil {
+			return fmt.Errorf("invalid cell template: %v", err)
+		}
+	}
+
+	if flags.MinFrameDelay < 0 {
+		return fmt.Errorf("min frame delay can't be negative")
+	}
+
+	if flags.TargetFPS < 0 {
+		return fmt.Errorf("target fps can't be negative")
+	}
+
+	// Gif delays are stored in 100ths of a second, so any faster frames would round down to 0 delays
+	if flags.TargetFPS > 100 {
+		return fmt.Errorf("target fps %v is above the maximum of 100", flags.TargetFPS)
+	}
+
+	if flags.LuminanceChannel != "" && !luminanceChannels[flags.LuminanceChannel] {
+		return fmt.Errorf("invalid luminance channel %v, must be one of luma, r, g, b, alpha, max or min", flags.LuminanceChannel)
+	}
+
+	if flags.StartTime < 0 || flags.Duration < 0 {
+		return fmt.Errorf("start time and duration can't be negative")
+	}
+
+	if flags.Vignette < 0 || flags.Vignette > 1 {
+		return fmt.Errorf("vignette must be from 0 to 1")
+	}
+
+	for _, value := range flags.BlendAlphaAgainst {
+		if value < 0 || value > 255 {
+			return fmt.Errorf("blend alpha color values must be from 0 to 255")
+		}
+	}
+
+	if flags.ScrollAnimation.Frames < 0 {
+		return fmt.Errorf("scroll animation frames can't be negative")
+	}
+
+	if flags.ScrollAnimation.Frames > 0 {
+		switch flags.ScrollAnimation.Direction {
+		case "right", "left", "down", "up":
+		default:
+			return fmt.Errorf("invalid scroll direction %v, must be one of right, left, down or up", flags.ScrollAnimation.Direction)
+		}
+
+		if flags.ScrollAnimation.ViewportWidth < 0 || flags.ScrollAnimation.ViewportHeight < 0 || flags.ScrollAnimation.FrameDelay < 0 {
+			return fmt.Errorf("scroll viewport dimensions and frame delay can't be negative")
+		}
+	}
+
+	if flags.TerminalBackground != [3]int{-1, -1, -1} {
+		for _, value := range flags.TerminalBackground {
+			if value < 0 || value > 255 {
+				return fmt.Errorf("terminal background color values must be from 0 to 255, or all -1 for detection")
+			}
+		}
+	}
+
+	if flags.Mosaic && (flags.Braille || flags.DotMode != "" || flags.AssumeGrayscale) {
+		return fmt.Errorf("mosaic can't be used with braille, dot mode or assume grayscale")
+	}
+
+	if flags.BinaryPreserve && (flags.Braille || flags.DotMode != "" || flags.Mosaic) {
+		return fmt.Errorf("binary preserve can't be used with braille, dot mode or mosaic")
+	}
+
+	if flags.ChannelSeparated {
+		if !flags.Braille && flags.DotMode == "" {
+			return fmt.Errorf("channel separated requires braille or dot mode")
+		}
+		if flags.AssumeGrayscale {
+			return fmt.Errorf("channel separated can't be used along with assume grayscale")
+		}
+		for _, value := range flags.ChannelThresholds {
+			if value < 0 || value > 255 {
+				return fmt.Errorf("channel thresholds must be between 0 and 255")
+			}
+		}
+	}
+
+	if flags.BrailleColorFull {
+		if !flags.Braille {
+			return fmt.Errorf("braille color full requires braille")
+		}
+		if flags.CharBackgroundColor || flags.ChannelSeparated {
+			return fmt.Errorf("braille color full can't be used along with character background color or channel separated")
+		}
+	}
+
+	if flags.Color16 {
+		for _, themeColor := range flags.Theme16 {
+			for _, value := range themeColor {
+				if value < 0 || value > 255 {
+					return fmt.Errorf("theme rgb values must be between 0 and 255")
+				}
+			}
+		}
+	}
+
+	if flags.DropShadow {
+		for _, value := range flags.ShadowColor[:3] {
+			if value < 0 || value > 255 {
+				return fmt.Errorf("shadow rgb values must be between 0 and 255")
+			}
+		}
+		if flags.ShadowColor[3] < 0 || flags.ShadowColor[3] > 100 {
+			return fmt.Errorf("shadow opacity must be between 0 and 100")
+		}
+	}
+
+	if flags.TermWidth < 0 || flags.TermHeight < 0 {
+		return fmt.Errorf("terminal width and height can't be negative")
+	}
+
+	if flags.Resampling == "" {
+		flags.Resampling = "lanczos"
+	}
+	if _, ok := resampleFilters[flags.Resampling]; !ok {
+		return fmt.Errorf("invalid resampling filter %v, must be one of lanczos, catmullrom, linear, box or nearest", flags.Resampling)
+	}
+
+	if flags.ColorTieBreak != "" && flags.ColorTieBreak != "lowest" && flags.ColorTieBreak != "highest" {
+		return fmt.Errorf("invalid color tie break %v, must be either lowest or highest", flags.ColorTieBreak)
+	}
+
+	if flags.TimeBudget < 0 {
+		return fmt.Errorf("time budget can't be negative")
+	}
+
+	if flags.OutputDPI < 0 {
+		return fmt.Errorf("output dpi can't be negative")
+	}
+
+	if flags.MatchSourceResolution && flags.PaperSize != "" {
+		return fmt.Errorf("match source resolution can't be used along with paper size")
+	}
+
+	if _, ok := paperSizes[flags.PaperSize]; flags.PaperSize != "" && !ok {
+		return fmt.Errorf("invalid paper size %v, must be one of a3, a4, a5, letter or legal", flags.PaperSize)
+	}
+
+	if flags.AdaptiveThreshold && flags.AdaptiveWindow < 3 {
+		return fmt.Errorf("adaptive threshold window must be at least 3")
+	}
+
+	if flags.WrapAt < 0 {
+		return fmt.Errorf("wrap column can't be negative")
+	}
+
+	if flags.EdgeThreshold < 0 {
+		return fmt.Errorf("edge threshold can't be negative")
+	}
+
+	if flags.WidthMultiple < 0 || flags.HeightMultiple < 0 {
+		return fmt.Errorf("width and height multiples can't be negative")
+	}
+
+	maskImage = nil
+	if flags.MaskPath != "" {
+		maskFile, err := os.Open(flags.MaskPath)
+		if err != nil {
+			return fmt.Errorf("unable to open mask file: %v", err)
+		}
+		maskImage, _, err = image.Decode(maskFile)
+		maskFile.Close()
+		if err != nil {
+			return fmt.Errorf("can't decode mask %v: %v", flags.MaskPath, err)
+		}
+	}
+
+	if flags.Dimensions == nil {
+		dimensions = nil
+	} else {
+		dimensions = flags.Dimensions
+	}
+	width = flags.Width
+	height = flags.Height
+	complex = flags.Complex
+	saveTxtPath = flags.SaveTxtPath
+	saveImagePath = flags.SaveImagePath
+	saveGifPath = flags.SaveGifPath
+	negative = flags.Negative
+	colored = flags.Colored
+	colorBg = flags.CharBackgroundColor
+	grayscale = flags.Grayscale
+	customMap = flags.CustomMap
+	flipX = flags.FlipX
+	flipY = flags.FlipY
+	full = flags.Full
+	fontPath = flags.FontFilePath
+	fontColor = flags.FontColor
+	saveBgColor = flags.SaveBackgroundColor
+	braille = flags.Braille
+	threshold = flags.Threshold
+	dither = flags.Dither
+	onlySave = flags.OnlySave
+	assumeGrayscale = flags.AssumeGrayscale
+	cursorSaveRest = flags.CursorSaveRestore
+	decodeModel = flags.DecodeColorModel
+	maxGlyphs = flags.MaxGlyphs
+	targetFPS = flags.TargetFPS
+	embedMetadata = flags.EmbedMetadata
+	streamDecode = flags.StreamDecode
+	dotMode = flags.DotMode
+	transposeOutput = flags.TransposeOutput
+	minFrameDelay = flags.MinFrameDelay
+	rampPreset = flags.RampPreset
+	rowSeparator = flags.RowSeparator
+	useThumbnail = flags.UseEmbeddedThumbnail
+	saveMoviePath = flags.SaveAnsiMoviePath
+	lumaChannel = flags.LuminanceChannel
+	startTime = flags.StartTime
+	clipDuration = flags.Duration
+	indent = flags.Indent
+	forceStatic = flags.ForceStatic
+	aspectFromFont = flags.AspectFromFont
+	vignette = flags.Vignette
+	setTitle = flags.SetTerminalTitle
+	widthMultiple = flags.WidthMultiple
+	heightMultiple = flags.HeightMultiple
+	blendColor = flags.BlendAlphaAgainst
+	linePrefix = flags.LinePrefix
+	lineSuffix = flags.LineSuffix
+	detailOverlay = flags.DetailOverlay
+	edgeThreshold = flags.EdgeThreshold
+	wrapAt = flags.WrapAt
+	scroll = flags.ScrollAnimation
+	adaptive = flags.AdaptiveThreshold
+	adaptiveWindow = flags.AdaptiveWindow
+	allowPartial = flags.AllowPartialDecode
+	outputDPI = flags.OutputDPI
+	paperSize = flags.PaperSize
+	timeBudget = flags.TimeBudget
+	premultiplied = flags.PremultipliedOutput
+	termWidth = flags.TermWidth
+	termHeight = flags.TermHeight
+	resampleFilter = resampleFilters[flags.Resampling]
+	singleLine = flags.SingleLineEscaped
+	blinkBrightest = flags.BlinkBrightest
+	concealDarkest = flags.ConcealDarkest
+	matchSource = flags.MatchSourceResolution
+	dropShadow = flags.DropShadow
+	shadowRGBA = flags.ShadowColor
+	shadowOffset = flags.ShadowOffset
+	separated = flags.ChannelSeparated
+	channelLimits = flags.ChannelThresholds
+	brailleFull = flags.BrailleColorFull
+	atomicWrites = flags.AtomicWrites
+	stableNoise = flags.StableNoise
+
+	imgManip.PreferHighestIndex = flags.ColorTieBreak == "highest"
+
+	imgManip.Color16Theme = nil
+	if flags.Color16 {
+		theme := flags.Theme16
+		if theme == [16][3]int{} {
+			theme = xtermTheme16
+		}
+		imgManip.Color16Theme = &theme
+	}
+
+	if flags.Mosaic {
+		customMap = "█"
+		colored = true
+		maxGlyphs = 0
+	}
+	if flags.BinaryPreserve {
+		customMap = " █"
+		colored = false
+		grayscale = false
+		maxGlyphs = 0
+		detailOverlay = false
+		resampleFilter = resampleFilters["box"]
+	}
+	if separated {
+		colored = true
+	}
+	if brailleFull && !grayscale {
+		colored = true
+	}
+	conversionStart = time.Now()
+
+	savesOutput := flags.SaveImagePath != "" || flags.SaveTxtPath != "" || flags.SaveGifPath != "" || flags.SaveAnsiMoviePath != ""
+
+	// Like wrapForTerminal(), blink and conceal codes are only added for terminal display, so that they
+	// can't end up in returned or saved ascii art
+	brightestIndex = 0
+	if (blinkBrightest || concealDarkest) && !savesOutput && isOutputTerminal() {
+		brightestIndex = len(getAtlasGlyphs()) - 1
+		if dotMode != "" {
+			// Bitmask of the full block, since shades follow QuadrantChars
+			brightestIndex = len(imgManip.QuadrantChars) - 1
+		}
+	}
+	if flags.AutoInvertForBackground && !savesOutput && isOutputTerminal() && terminalBackgroundIsLight(flags.TerminalBackground) {
+		negative = !negative
+	}
+	if rowSeparator == "" {
+		rowSeparator = "\n"
+	}
+	usedFlags = flags
+
+	return nil
+}
+
+// If path to font file is provided, use it
+func loadFont() error {
 	if fontPath != "" {
 		fontFile, err := ioutil.ReadFile(fontPath)
 		if err != nil {
-			return "", fmt.Errorf("unable to open font file: %v", err)
+			return fmt.Errorf("unable to open font file: %v", err)
 		}
 
 		// tempFont is globally declared in aic_package/create_ascii_image.go
 		if tempFont, err = truetype.Parse(fontFile); err != nil {
-			return "", fmt.Errorf("unable to parse font file: %v", err)
+			return fmt.Errorf("unable to parse font file: %v", err)
 		}
 	} else if braille {
 		tempFont, _ = truetype.Parse(embeddedDejaVuObliqueFont)
 	}
 
-	if inputIsGif {
-		return "", pathIsGif(filePath, urlImgName, pathIsURl, urlImgBytes, pipedInputBytes, localFile)
-	} else {
-		return pathIsImage(filePath, urlImgName, pathIsURl, urlImgBytes, pipedInputBytes, localFile)
+	if aspectFromFont {
+		fontFace := truetype.NewFace(tempFont, &truetype.Options{Size: savedImageCharWidth * 1.5})
+		fontCellWidth, fontCellHeight = getFontCellSize(getAtlasGlyphs(), fontFace)
+	}
+
+	// Saved images would silently show blank boxes if the font lacks block characters
+	if dotMode != "" && (saveImagePath != "" || saveGifPath != "") {
+		for _, char := range getAtlasGlyphs() {
+			if char != " " && tempFont.Index([]rune(char)[0]) == 0 {
+				fmt.Fprintln(os.Stderr, "Warning: font is missing some block characters used by dot mode "+dotMode+
+					", which needs the Unicode Block Elements and Symbols for Legacy Computing (U+1FB00 to U+1FBFF)")
+				break
+			}
+		}
 	}
+
+	return nil
 }
@@ -0,0 +1,102 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"unicode"
+
+	imgManip "github.com/TheZoraiz/ascii-image-converter/image_manipulation"
+	eastAsianWidth "golang.org/x/text/width"
+)
+
+/*
+ConvertRunes() takes the same arguments as Convert(), but instead of the ascii art string, it returns
+the character of each cell of the ascii art, along with a grid of the same dimensions holding the number
+of terminal columns each character takes up. This allows laying out content around ascii art made of
+characters that aren't all one column wide, e.g. CJK characters or emoji in Flags.CustomMap.
+
+Widths are taken from the Unicode East Asian Width property. Wide and fullwidth characters take 2 columns,
+combining marks and other zero width characters take 0, and everything else, including ambiguous width
+characters, takes 1. Terminals configured to show ambiguous width characters as wide will differ.
+
+Gifs are treated as still images and only their first frame is converted. Nothing is printed or saved.
+*/
+func ConvertRunes(filePath string, flags Flags) ([][]rune, [][]int, error) {
+
+	if err := setFlags(flags); err != nil {
+		return nil, nil, err
+	}
+
+	input, err := readInput(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer input.close()
+
+	imData, err := decodeImage(filePath, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	asciiSet, err := convertImageToAsciiSet(imData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	runes, widths := asciiSetToRunes(asciiSet)
+
+	return runes, widths, nil
+}
+
+// Returns the character of each cell of asciiSet, along with its width in terminal columns
+func asciiSetToRunes(asciiSet [][]imgManip.AsciiChar) ([][]rune, [][]int) {
+
+	runes := make([][]rune, len(asciiSet))
+	widths := make([][]int, len(asciiSet))
+
+	for i, line := range asciiSet {
+		runes[i] = make([]rune, len(line))
+		widths[i] = make([]int, len(line))
+
+		for j, char := range line {
+			charRunes := []rune(char.Simple)
+
+			runes[i][j] = ' '
+			if len(charRunes) > 0 {
+				runes[i][j] = charRunes[0]
+			}
+			widths[i][j] = runeWidth(runes[i][j])
+		}
+	}
+
+	return runes, widths
+}
+
+// Returns the number of terminal columns r takes up, as described for ConvertRunes()
+func runeWidth(r rune) int {
+
+	if r == 0 || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+
+	switch eastAsianWidth.LookupRune(r).Kind() {
+	case eastAsianWidth.EastAsianWide, eastAsianWidth.EastAsianFullwidth:
+		return 2
+	}
+
+	return 1
+}
@@ -0,0 +1,95 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+// This function crops frames of Flags.ScrollAnimation from the passed image and turns them into an ascii art animation through convertFrames()
+func pathIsScroll(imagePath string, input inputData) error {
+
+	imData, err := decodeImage(imagePath, input)
+	if err != nil {
+		return err
+	}
+
+	bounds := imData.Bounds()
+
+	viewportWidth, viewportHeight := scroll.ViewportWidth, scroll.ViewportHeight
+	if viewportWidth == 0 {
+		viewportWidth = bounds.Dx()
+	}
+	if viewportHeight == 0 {
+		viewportHeight = bounds.Dy()
+	}
+
+	if viewportWidth > bounds.Dx() || viewportHeight > bounds.Dy() {
+		return fmt.Errorf("scroll viewport %vx%v doesn't fit image dimensions %vx%v", viewportWidth, viewportHeight, bounds.Dx(), bounds.Dy())
+	}
+
+	// Distance the viewport moves from its first position to its last one
+	spanX := bounds.Dx() - viewportWidth
+	spanY := bounds.Dy() - viewportHeight
+
+	frames := make([]image.Image, scroll.Frames)
+	delays := make([]int, scroll.Frames)
+
+	for i := range frames {
+
+		// Fraction of the way across the image, from 0 in first frame to 1 in last frame
+		progress := 0.0
+		if scroll.Frames > 1 {
+			progress = float64(i) / float64(scroll.Frames-1)
+		}
+
+		var offsetX, offsetY int
+		switch scroll.Direction {
+		case "right":
+			offsetX = int(progress * float64(spanX))
+		case "left":
+			offsetX = spanX - int(progress*float64(spanX))
+		case "down":
+			offsetY = int(progress * float64(spanY))
+		case "up":
+			offsetY = spanY - int(progress*float64(spanY))
+		}
+
+		viewport := image.Rect(0, 0, viewportWidth, viewportHeight).Add(bounds.Min).Add(image.Pt(offsetX, offsetY))
+
+		frames[i] = cropImage(imData, viewport)
+		delays[i] = scroll.FrameDelay
+	}
+
+	return convertFrames(frames, delays, 0, imagePath, input)
+}
+
+// Returns the part of img inside rect, without copying it if img supports sub images
+func cropImage(img image.Image, rect image.Rectangle) image.Image {
+
+	if subImager, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return subImager.SubImage(rect)
+	}
+
+	cropped := image.NewNRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(cropped, cropped.Bounds(), img, rect.Min, draw.Src)
+	return cropped
+}
@@ -0,0 +1,129 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type FramingSpec struct {
+	// How images are separated in the stream read by StreamConvert(). Accepts "length", where
+	// each image is preceded by its size in bytes as a 4 byte big endian unsigned integer
+	Mode string
+
+	// Written after each converted ascii art. If left empty, each ascii art is instead
+	// preceded by its size in bytes in the same way as images in Mode "length"
+	Delimiter string
+
+	// Largest size in bytes accepted for an image in Mode "length". A larger size returns an
+	// error before anything is allocated for the image. Defaults to defaultMaxStreamFrameSize if 0
+	MaxFrameSize int
+}
+
+// Largest image size accepted by StreamConvert() if FramingSpec.MaxFrameSize isn't set
+const defaultMaxStreamFrameSize = 64 << 20
+
+/*
+StreamConvert() reads a stream of images from r, separated according to framing, and writes the ascii art
+of each image to w as soon as it's converted, until r ends. This allows converting images produced by
+another process, like video frames, without starting a new process for each of them.
+
+Each image is converted like Convert() would, apart from gifs, which are treated as still images with only
+their first frame converted. Saving flags are ignored. If r ends in the middle of an image, an image is
+larger than FramingSpec.MaxFrameSize, or an image can't be converted, the error is returned and the
+stream isn't read any further.
+*/
+func StreamConvert(r io.Reader, w io.Writer, flags Flags, framing FramingSpec) error {
+
+	if framing.Mode != "length" {
+		return fmt.Errorf("invalid framing mode %v, only length is supported", framing.Mode)
+	}
+
+	if framing.MaxFrameSize < 0 {
+		return fmt.Errorf("invalid max frame size %v, must be 0 or above", framing.MaxFrameSize)
+	}
+
+	maxFrameSize := framing.MaxFrameSize
+	if maxFrameSize == 0 {
+		maxFrameSize = defaultMaxStreamFrameSize
+	}
+
+	if err := setFlags(flags); err != nil {
+		return err
+	}
+
+	if err := loadFont(); err != nil {
+		return err
+	}
+
+	lengthBytes := make([]byte, 4)
+
+	for frameCount := 0; ; frameCount++ {
+
+		if _, err := io.ReadFull(r, lengthBytes); err == io.EOF {
+			// Stream ended between images
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("can't read length of image %v: %v", frameCount, err)
+		}
+
+		frameSize := binary.BigEndian.Uint32(lengthBytes)
+		if uint64(frameSize) > uint64(maxFrameSize) {
+			return fmt.Errorf("image %v is %v bytes, more than the max frame size of %v bytes", frameCount, frameSize, maxFrameSize)
+		}
+
+		var input inputData
+		input.pipedInputBytes = make([]byte, frameSize)
+		if _, err := io.ReadFull(r, input.pipedInputBytes); err != nil {
+			return fmt.Errorf("can't read image %v: %v", frameCount, err)
+		}
+
+		imData, err := decodeImage("-", input)
+		if err != nil {
+			return fmt.Errorf("image %v: %v", frameCount, err)
+		}
+
+		asciiSet, err := convertImageToAsciiSet(imData)
+		if err != nil {
+			return fmt.Errorf("image %v: %v", frameCount, err)
+		}
+
+		var asciiArt string
+		if cellTemplate != nil {
+			if asciiArt, err = executeCellTemplate(asciiSet); err != nil {
+				return fmt.Errorf("image %v: %v", frameCount, err)
+			}
+		} else {
+			asciiArt = strings.Join(flattenAscii(asciiSet, colored || grayscale, false), "\n")
+		}
+
+		if framing.Delimiter != "" {
+			_, err = io.WriteString(w, asciiArt+framing.Delimiter)
+		} else {
+			binary.BigEndian.PutUint32(lengthBytes, uint32(len(asciiArt)))
+			if _, err = w.Write(lengthBytes); err == nil {
+				_, err = io.WriteString(w, asciiArt)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
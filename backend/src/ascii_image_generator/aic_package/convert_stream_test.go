@@ -0,0 +1,63 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestStreamConvertMaxFrameSize(t *testing.T) {
+	var frame bytes.Buffer
+	if err := png.Encode(&frame, image.NewGray(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Returns a stream of a single length prefixed frame, claiming to be size bytes long
+	stream := func(size uint32) *bytes.Reader {
+		var buf bytes.Buffer
+		binary.Write(&buf, binary.BigEndian, size)
+		buf.Write(frame.Bytes())
+		return bytes.NewReader(buf.Bytes())
+	}
+
+	flags := DefaultFlags()
+	flags.Dimensions = []int{4, 2}
+
+	framing := FramingSpec{Mode: "length", Delimiter: "\n", MaxFrameSize: frame.Len()}
+	if err := StreamConvert(stream(uint32(frame.Len())), ioutil.Discard, flags, framing); err != nil {
+		t.Fatalf("frame of exactly MaxFrameSize: %v", err)
+	}
+
+	framing.MaxFrameSize = frame.Len() - 1
+	err := StreamConvert(stream(uint32(frame.Len())), ioutil.Discard, flags, framing)
+	if err == nil || !strings.Contains(err.Error(), "max frame size") {
+		t.Fatalf("frame over MaxFrameSize: got %v, want max frame size error", err)
+	}
+
+	// A corrupt length near 4 GiB has to fail on the default limit instead of being allocated
+	framing.MaxFrameSize = 0
+	err = StreamConvert(stream(0xffffffff), ioutil.Discard, flags, framing)
+	if err == nil || !strings.Contains(err.Error(), "max frame size") {
+		t.Fatalf("frame over default max frame size: got %v, want max frame size error", err)
+	}
+}
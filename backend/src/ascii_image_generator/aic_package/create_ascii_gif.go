@@ -70,11 +70,11 @@ func createGifFrameToSave(asciiArt [][]imgManip.AsciiChar, img image.Image, colo
 		fontSize = xIter
 	}
 
-	// 10 extra pixels on both x and y-axis to have 5 pixels of padding on each side
-	x += 10
-	y += 10
-
-	tempImg := image.NewRGBA(image.Rect(0, 0, x, y))
+	// 10 extra pixels on both x and y-axis to have 5 pixels of padding on each side,
+	// along with space for the shadow of Flags.DropShadow
+	left, top, right, bottom := getShadowMargins()
+	x += 10 + left + right
+	y += 10 + top + bottom
 
 	dc := gg.NewContext(x, y)
 
@@ -86,55 +86,17 @@ func createGifFrameToSave(asciiArt [][]imgManip.AsciiChar, img image.Image, colo
 	)
 	dc.Clear()
 
-	dc.DrawImage(tempImg, 0, 0)
-
 	// Font size increased during assignment to become more visible. This will not affect image drawing
 	fontFace := truetype.NewFace(tempFont, &truetype.Options{Size: fontSize * 1.5})
 
-	dc.SetFontFace(fontFace)
-
-	// Font color of text on picture is white by default
-	dc.SetColor(color.White)
-
-	// Pointer to track y-axis on the image frame
-	yImgPointer := 5.0
-
-	// These nested loops print each character in asciArt 2D slice separately
-	// so that their RGB colors can be maintained in the resulting image
-	for _, line := range asciiArt {
-
-		// Pointer to track x-axis on the image frame
-		xImgPointer := 5.0
-
-		for _, char := range line {
-
-			if colored {
-				// dc.SetColor() sets color for EACH character before printing it
-				r := uint8(char.RgbValue[0])
-				g := uint8(char.RgbValue[1])
-				b := uint8(char.RgbValue[2])
-				dc.SetColor(color.RGBA{r, g, b, 255})
-
-			} else {
-				r := uint8(fontColor[0])
-				g := uint8(fontColor[1])
-				b := uint8(fontColor[2])
-				dc.SetColor(color.RGBA{r, g, b, 255})
-			}
-
-			dc.DrawStringWrapped(char.Simple, xImgPointer, yImgPointer, 0, 0, float64(x), 1.8, gg.AlignLeft)
-
-			// Incremet x-axis pointer character so new one can be printed after it
-			// Set to the same constant as in line
-			xImgPointer += xIter
-		}
-
-		dc.DrawStringWrapped("\n", xImgPointer, yImgPointer, 0, 0, float64(x), 1.8, gg.AlignLeft)
+	drawGlyphs := func(dc *gg.Context, glyphColor func(imgManip.AsciiChar) color.Color) {
+		drawAsciiGlyphs(dc, asciiArt, fontFace, float64(5+left), float64(5+top), xIter, yIter, glyphColor)
+	}
 
-		// Incremet pointer for y axis after every line printed, so
-		// new line can start at below the previous one on next iteration
-		yImgPointer += yIter
+	if dropShadow {
+		drawDropShadow(dc, drawGlyphs)
 	}
+	drawGlyphs(dc, getGlyphColor(colored))
 
 	return dc.Image(), nil
 }
@@ -17,15 +17,24 @@ limitations under the License.
 package aic_package
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"hash/crc32"
 	"image"
 	"image/color"
+	"image/png"
+	"math"
+	"time"
 
 	_ "embed"
 
 	imgManip "github.com/TheZoraiz/ascii-image-converter/image_manipulation"
 	"github.com/fogleman/gg"
 	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
 )
 
 //go:embed Hack-Regular.ttf
@@ -36,6 +45,18 @@ var embeddedDejaVuObliqueFont []byte
 
 var tempFont *truetype.Font
 
+// Width of each character in saved ascii art images, in pixels. Characters are twice as tall
+const savedImageCharWidth = 14.0
+
+// Width and height of each Flags.PaperSize in millimeters
+var paperSizes = map[string][2]float64{
+	"a3":     {297, 420},
+	"a4":     {210, 297},
+	"a5":     {148, 210},
+	"letter": {215.9, 279.4},
+	"legal":  {215.9, 355.6},
+}
+
 // Load embedded font
 func init() {
 	tempFont, _ = truetype.Parse(embeddedHackRegularFont)
@@ -51,27 +72,191 @@ Size of resulting image may also be considerably larger than original image.
 */
 func createImageToSave(asciiArt [][]imgManip.AsciiChar, colored bool, saveImagePath, imagePath, urlImgName string, onlySave bool) error {
 
-	constant := 14.0
+	imageName, err := createSaveFileName(imagePath, urlImgName, "-ascii-art.png")
+	if err != nil {
+		return err
+	}
 
-	x := len(asciiArt[0])
-	y := len(asciiArt)
+	fullPathName, err := getFullSavePath(imageName, saveImagePath)
+	if err != nil {
+		return err
+	}
 
-	// Multipying resulting image dimensions with respect to constant
-	x = int(constant * float64(x))
+	if onlySave {
+		fmt.Println("Saved " + fullPathName)
+	}
 
-	y = int(constant * float64(y))
-	y = y * 2
+	pngBytes, err := encodeAsciiPng(asciiArt, colored, imagePath)
+	if err != nil {
+		return err
+	}
+
+	return writeSaveFile(fullPathName, pngBytes)
+}
 
-	// 10 extra pixels on both x and y-axis to have 5 pixels of padding on each side
-	y += 10
-	x += 10
+/*
+Draws the passed ascii art with drawAsciiImage() and encodes it as png data, with the pHYs chunk of
+Flags.OutputDPI and the iTXt chunks of Flags.EmbedMetadata, in which imagePath is the source. Every
+png output, whether saved, returned or written to an OutputTarget, is encoded through this
+*/
+func encodeAsciiPng(asciiArt [][]imgManip.AsciiChar, colored bool, imagePath string) ([]byte, error) {
 
-	tempImg := image.NewRGBA(image.Rect(0, 0, x, y))
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, drawAsciiImage(asciiArt, colored)); err != nil {
+		return nil, err
+	}
 
-	imgWidth := tempImg.Bounds().Dx()
-	imgHeight := tempImg.Bounds().Dy()
+	pngBytes := buf.Bytes()
+	if outputDPI > 0 {
+		var err error
+		if pngBytes, err = insertPngPhysChunk(pngBytes, outputDPI); err != nil {
+			return nil, err
+		}
+	}
+
+	if !embedMetadata {
+		return pngBytes, nil
+	}
+
+	flagsJson, err := json.Marshal(usedFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	source := imagePath
+	if imagePath == "-" {
+		source = "piped input"
+	}
+
+	pngBytes, err = insertPngTextChunks(pngBytes, [][2]string{
+		{"Source", source},
+		{"Creation Time", time.Now().Format(time.RFC3339)},
+		{"Software", "ascii-image-converter"},
+		{"Flags", string(flagsJson)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pngBytes, nil
+}
+
+/*
+Inserts an iTXt chunk for each keyword and text pair right after the IHDR chunk of the passed png data.
+Go's png encoder doesn't support writing text chunks, so they're added to already encoded data instead.
+iTXt is used rather than tEXt since texts are UTF-8, like paths and JSON with non-ASCII characters,
+while tEXt only allows Latin-1. Keywords must be Latin-1 either way
+*/
+func insertPngTextChunks(pngBytes []byte, texts [][2]string) ([]byte, error) {
+
+	// 8 byte png signature followed by IHDR chunk, which has 13 bytes of data along with
+	// 4 bytes each for its length, type and crc
+	ihdrEnd := 8 + 4 + 4 + 13 + 4
+
+	if len(pngBytes) < ihdrEnd || string(pngBytes[12:16]) != "IHDR" {
+		return nil, fmt.Errorf("invalid png data")
+	}
+
+	var buf bytes.Buffer
+	buf.Write(pngBytes[:ihdrEnd])
+
+	for _, text := range texts {
+		// Keyword, then uncompressed flag and method bytes, then empty language tag and
+		// translated keyword, each ended by a null byte, followed by the UTF-8 text
+		data := append([]byte(text[0]+"\x00\x00\x00\x00\x00"), []byte(text[1])...)
+
+		// Length, type, data and crc of type along with data
+		chunk := make([]byte, 12+len(data))
+		binary.BigEndian.PutUint32(chunk[:4], uint32(len(data)))
+		copy(chunk[4:8], "iTXt")
+		copy(chunk[8:], data)
+		binary.BigEndian.PutUint32(chunk[8+len(data):], crc32.ChecksumIEEE(chunk[4:8+len(data)]))
+
+		buf.Write(chunk)
+	}
+
+	buf.Write(pngBytes[ihdrEnd:])
+
+	return buf.Bytes(), nil
+}
+
+// Inserts a pHYs chunk with the pixels per meter for dpi right after the IHDR chunk of the passed png data
+func insertPngPhysChunk(pngBytes []byte, dpi int) ([]byte, error) {
+
+	ihdrEnd := 8 + 4 + 4 + 13 + 4
+
+	if len(pngBytes) < ihdrEnd || string(pngBytes[12:16]) != "IHDR" {
+		return nil, fmt.Errorf("invalid png data")
+	}
+
+	pixelsPerMeter := uint32(math.Round(float64(dpi) / 0.0254))
+
+	// Pixels per unit along x and y-axis, followed by 1 for the unit being meters
+	chunk := make([]byte, 12+9)
+	binary.BigEndian.PutUint32(chunk[:4], 9)
+	copy(chunk[4:8], "pHYs")
+	binary.BigEndian.PutUint32(chunk[8:12], pixelsPerMeter)
+	binary.BigEndian.PutUint32(chunk[12:16], pixelsPerMeter)
+	chunk[16] = 1
+	binary.BigEndian.PutUint32(chunk[17:], crc32.ChecksumIEEE(chunk[4:17]))
+
+	var buf bytes.Buffer
+	buf.Write(pngBytes[:ihdrEnd])
+	buf.Write(chunk)
+	buf.Write(pngBytes[ihdrEnd:])
+
+	return buf.Bytes(), nil
+}
 
-	dc := gg.NewContext(imgWidth, imgHeight)
+// Draws the passed ascii art on an image with a fixed font size, as described for createImageToSave()
+func drawAsciiImage(asciiArt [][]imgManip.AsciiChar, colored bool) image.Image {
+
+	constant := savedImageCharWidth
+
+	fontFace := truetype.NewFace(tempFont, &truetype.Options{Size: constant * 1.5})
+
+	// Each character takes a cell twice as tall as it's wide, matching how ascii art is
+	// resized for terminals, unless its dimensions are taken from font metrics
+	cellWidth, cellHeight := constant, constant*2
+	if aspectFromFont {
+		cellWidth, cellHeight = fontCellWidth, fontCellHeight
+	}
+
+	// Cell dimensions are proportional to font size, so they're scaled along with it
+	if outputDPI > 0 && paperSize != "" {
+		scale := getPaperScale(len(asciiArt[0]), len(asciiArt), cellWidth, cellHeight)
+
+		constant *= scale
+		cellWidth *= scale
+		cellHeight *= scale
+		fontFace = truetype.NewFace(tempFont, &truetype.Options{Size: constant * 1.5})
+	}
+
+	// Cells are stretched to fill the source dimensions, as described for Flags.MatchSourceResolution.
+	// Their size is nudged up, so that rounding errors can't lose a pixel of the resulting image
+	if matchSource {
+		fittedWidth := math.Max((float64(sourceWidth)-10)/float64(len(asciiArt[0])), 1) + 1e-9
+		fittedHeight := math.Max((float64(sourceHeight)-10)/float64(len(asciiArt)), 1) + 1e-9
+
+		constant *= math.Min(fittedWidth/cellWidth, fittedHeight/cellHeight)
+		cellWidth, cellHeight = fittedWidth, fittedHeight
+		fontFace = truetype.NewFace(tempFont, &truetype.Options{Size: constant * 1.5})
+	}
+
+	x := len(asciiArt[0])
+	y := len(asciiArt)
+
+	// Multipying resulting image dimensions with respect to cell dimensions
+	x = int(cellWidth * float64(x))
+	y = int(cellHeight * float64(y))
+
+	// 10 extra pixels on both x and y-axis to have 5 pixels of padding on each side,
+	// along with space for the shadow of Flags.DropShadow
+	left, top, right, bottom := getShadowMargins()
+	y += 10 + top + bottom
+	x += 10 + left + right
+
+	dc := gg.NewContext(x, y)
 
 	// Set image background
 	dc.SetRGBA(
@@ -82,67 +267,130 @@ func createImageToSave(asciiArt [][]imgManip.AsciiChar, colored bool, saveImageP
 	)
 	dc.Clear()
 
-	dc.DrawImage(tempImg, 0, 0)
+	drawGlyphs := func(dc *gg.Context, glyphColor func(imgManip.AsciiChar) color.Color) {
+		drawAsciiGlyphs(dc, asciiArt, fontFace, float64(5+left), float64(5+top), cellWidth, cellHeight, glyphColor)
+	}
+
+	if dropShadow {
+		drawDropShadow(dc, drawGlyphs)
+	}
+	drawGlyphs(dc, getGlyphColor(colored))
+
+	if premultiplied {
+		return premultipliedAsStraight(dc.Image())
+	}
+
+	// Encoding the drawn image, which holds premultiplied colors, converts them to straight colors
+	return dc.Image()
+}
+
+/*
+Draws each character of asciiArt on dc in its own cell of cellWidth x cellHeight, starting from originX and
+originY, in the color glyphColor returns for it. Characters are drawn separately so that their colors can be
+maintained in the resulting image
+*/
+func drawAsciiGlyphs(dc *gg.Context, asciiArt [][]imgManip.AsciiChar, fontFace font.Face, originX, originY, cellWidth, cellHeight float64, glyphColor func(imgManip.AsciiChar) color.Color) {
 
-	fontFace := truetype.NewFace(tempFont, &truetype.Options{Size: constant * 1.5})
 	dc.SetFontFace(fontFace)
 
-	// Font color of text on picture is white by default
-	dc.SetColor(color.White)
+	wrapWidth := float64(dc.Width())
 
 	// Pointer to track y-axis on the image frame
-	yImgPointer := 5.0
+	yImgPointer := originY
 
-	// These nested loops print each character in asciArt 2D slice separately
-	// so that their RGB colors can be maintained in the resulting image
 	for _, line := range asciiArt {
 
 		// Pointer to track x-axis on the image frame
-		xImgPointer := 5.0
+		xImgPointer := originX
 
 		for _, char := range line {
 
-			if colored {
-				// dc.SetColor() sets color for EACH character before printing it
-				r := uint8(char.RgbValue[0])
-				g := uint8(char.RgbValue[1])
-				b := uint8(char.RgbValue[2])
-				dc.SetColor(color.RGBA{r, g, b, 255})
-
-			} else {
-				r := uint8(fontColor[0])
-				g := uint8(fontColor[1])
-				b := uint8(fontColor[2])
-				dc.SetColor(color.RGBA{r, g, b, 255})
-			}
+			// dc.SetColor() sets color for EACH character before printing it
+			dc.SetColor(glyphColor(char))
 
-			dc.DrawStringWrapped(char.Simple, xImgPointer, yImgPointer, 0, 0, float64(x), 1.8, gg.AlignLeft)
+			dc.DrawStringWrapped(char.Simple, xImgPointer, yImgPointer, 0, 0, wrapWidth, 1.8, gg.AlignLeft)
 
 			// Incremet x-axis pointer character so new one can be printed after it
-			// Set to the same constant as in line
-			xImgPointer += float64(constant)
+			xImgPointer += cellWidth
 		}
 
-		dc.DrawStringWrapped("\n", xImgPointer, yImgPointer, 0, 0, float64(x), 1.8, gg.AlignLeft)
+		dc.DrawStringWrapped("\n", xImgPointer, yImgPointer, 0, 0, wrapWidth, 1.8, gg.AlignLeft)
 
 		// Incremet pointer for y axis after every line printed, so
 		// new line can start at below the previous one on next iteration
-		yImgPointer += float64(constant * 2)
+		yImgPointer += cellHeight
+	}
+}
+
+// Returns the color of characters in saved images, which is their original color if colored is true
+// and Flags.FontColor otherwise
+func getGlyphColor(colored bool) func(imgManip.AsciiChar) color.Color {
+	return func(char imgManip.AsciiChar) color.Color {
+		if colored {
+			return color.RGBA{uint8(char.RgbValue[0]), uint8(char.RgbValue[1]), uint8(char.RgbValue[2]), 255}
+		}
+		return color.RGBA{uint8(fontColor[0]), uint8(fontColor[1]), uint8(fontColor[2]), 255}
 	}
+}
 
-	imageName, err := createSaveFileName(imagePath, urlImgName, "-ascii-art.png")
-	if err != nil {
-		return err
+// Returns img with its premultiplied color values stored as straight colors, so they're encoded as they are
+func premultipliedAsStraight(img image.Image) image.Image {
+
+	b := img.Bounds()
+	straight := image.NewNRGBA(b)
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			straight.SetNRGBA(x, y, color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(bl >> 8), uint8(a >> 8)})
+		}
 	}
 
-	fullPathName, err := getFullSavePath(imageName, saveImagePath)
-	if err != nil {
-		return err
+	return straight
+}
+
+/*
+Returns the factor that cells of cellWidth x cellHeight need to be scaled by for cols x rows of them, along
+with the 5 pixel padding on each side, to fill Flags.PaperSize at Flags.OutputDPI
+*/
+func getPaperScale(cols, rows int, cellWidth, cellHeight float64) float64 {
+
+	paper := paperSizes[paperSize]
+	paperWidth := paper[0] / 25.4 * float64(outputDPI)
+	paperHeight := paper[1] / 25.4 * float64(outputDPI)
+
+	artWidth := cellWidth * float64(cols)
+	artHeight := cellHeight * float64(rows)
+
+	fitScale := func(sheetWidth, sheetHeight float64) float64 {
+		return math.Min((sheetWidth-10)/artWidth, (sheetHeight-10)/artHeight)
 	}
 
-	if onlySave {
-		fmt.Println("Saved " + fullPathName)
+	// Use whichever orientation of the paper fits larger ascii art
+	return math.Max(fitScale(paperWidth, paperHeight), fitScale(paperHeight, paperWidth))
+}
+
+// Returns the width and height of a character cell according to metrics of fontFace. Width is the largest
+// advance among glyphs, so that proportional fonts don't overlap, and height is the line height
+func getFontCellSize(glyphs []string, fontFace font.Face) (float64, float64) {
+
+	var advance fixed.Int26_6
+	for _, glyph := range glyphs {
+		if glyph == "" {
+			continue
+		}
+		if glyphAdvance, ok := fontFace.GlyphAdvance([]rune(glyph)[0]); ok && glyphAdvance > advance {
+			advance = glyphAdvance
+		}
+	}
+
+	cellWidth := float64(advance) / 64
+	cellHeight := float64(fontFace.Metrics().Height) / 64
+
+	// Fonts missing every character of the ascii art fall back to the usual cell
+	if cellWidth == 0 || cellHeight == 0 {
+		return savedImageCharWidth, savedImageCharWidth * 2
 	}
 
-	return dc.SavePNG(fullPathName)
+	return cellWidth, cellHeight
 }
@@ -0,0 +1,182 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Writes img as a png to a temporary directory and returns its path, along with a function removing it
+func writeTestPng(t *testing.T, img image.Image) (string, func()) {
+	dir, err := ioutil.TempDir("", "aic-image")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	imagePath := filepath.Join(dir, "test.png")
+	if err := ioutil.WriteFile(imagePath, buf.Bytes(), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	return imagePath, func() { os.RemoveAll(dir) }
+}
+
+func TestPremultipliedOutput(t *testing.T) {
+	// White characters over a half transparent blue background, so that glyph edges are translucent
+	white := image.NewNRGBA(image.Rect(0, 0, 8, 4))
+	draw.Draw(white, white.Bounds(), image.White, image.Point{}, draw.Src)
+
+	imagePath, cleanup := writeTestPng(t, white)
+	defer cleanup()
+
+	render := func(premultiplied bool) *image.NRGBA {
+		flags := DefaultFlags()
+		flags.Dimensions = []int{4, 2}
+		flags.SaveBackgroundColor = [4]int{0, 0, 255, 50}
+		flags.PremultipliedOutput = premultiplied
+
+		data, err := ConvertToImageBytes(imagePath, flags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		nrgba := image.NewNRGBA(img.Bounds())
+		draw.Draw(nrgba, nrgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		return nrgba
+	}
+
+	straight := render(false)
+	premultiplied := render(true)
+
+	// The padding around characters only shows the background
+	if got := straight.NRGBAAt(0, 0); got != (color.NRGBA{0, 0, 255, 127}) {
+		t.Errorf("straight background: got %v, want {0 0 255 127}", got)
+	}
+	if got := premultiplied.NRGBAAt(0, 0); got != (color.NRGBA{0, 0, 127, 127}) {
+		t.Errorf("premultiplied background: got %v, want {0 0 127 127}", got)
+	}
+
+	// Every pixel, including translucent glyph edges, is the straight color multiplied by its alpha
+	translucentGlyphPixels := 0
+	b := straight.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			s, p := straight.NRGBAAt(x, y), premultiplied.NRGBAAt(x, y)
+
+			if s.A != p.A {
+				t.Fatalf("pixel %v,%v: alpha %v straight and %v premultiplied", x, y, s.A, p.A)
+			}
+			for i, channel := range [3][2]uint8{{s.R, p.R}, {s.G, p.G}, {s.B, p.B}} {
+				if diff := int(channel[0])*int(s.A)/255 - int(channel[1]); diff < -2 || diff > 2 {
+					t.Fatalf("pixel %v,%v channel %v: %v straight and %v premultiplied at alpha %v", x, y, i, channel[0], channel[1], s.A)
+				}
+			}
+
+			if s.R > 0 && s.A < 255 {
+				translucentGlyphPixels++
+			}
+		}
+	}
+
+	if translucentGlyphPixels == 0 {
+		t.Errorf("no translucent glyph pixels were drawn")
+	}
+}
+
+// Returns the type of each chunk in the passed png data, in order
+func pngChunkTypes(t *testing.T, pngBytes []byte) []string {
+	var types []string
+	for i := 8; i+8 <= len(pngBytes); {
+		length := int(pngBytes[i])<<24 | int(pngBytes[i+1])<<16 | int(pngBytes[i+2])<<8 | int(pngBytes[i+3])
+		types = append(types, string(pngBytes[i+4:i+8]))
+		i += 4 + 4 + length + 4
+	}
+	if len(types) == 0 || types[len(types)-1] != "IEND" {
+		t.Fatalf("png data ends without an IEND chunk: %v", types)
+	}
+	return types
+}
+
+func TestPngOutputChunks(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 4, 2))
+	imagePath, cleanup := writeTestPng(t, img)
+	defer cleanup()
+
+	flags := DefaultFlags()
+	flags.Dimensions = []int{4, 2}
+	flags.OutputDPI = 300
+	flags.EmbedMetadata = true
+
+	imageBytes, err := ConvertToImageBytes(imagePath, flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	multiPath := filepath.Join(filepath.Dir(imagePath), "multi.png")
+	results, err := ConvertMulti(imagePath, flags, []OutputTarget{{Name: "png", Format: "png", Path: multiPath}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results["png"] != nil {
+		t.Fatal(results["png"])
+	}
+	multiBytes, err := ioutil.ReadFile(multiPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saveFlags := flags
+	saveFlags.SaveImagePath = filepath.Dir(imagePath)
+	if _, err := Convert(imagePath, saveFlags); err != nil {
+		t.Fatal(err)
+	}
+	savedBytes, err := ioutil.ReadFile(filepath.Join(saveFlags.SaveImagePath, "test-ascii-art.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := map[string][]byte{"image bytes": imageBytes, "multi": multiBytes, "saved": savedBytes}
+	for name, pngBytes := range outputs {
+		counts := map[string]int{}
+		for _, chunkType := range pngChunkTypes(t, pngBytes) {
+			counts[chunkType]++
+		}
+		if counts["pHYs"] != 1 {
+			t.Errorf("%v: got %v pHYs chunks, want 1", name, counts["pHYs"])
+		}
+		if counts["iTXt"] != 4 {
+			t.Errorf("%v: got %v iTXt chunks, want 4", name, counts["iTXt"])
+		}
+	}
+}
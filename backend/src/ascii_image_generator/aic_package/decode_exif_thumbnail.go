@@ -0,0 +1,157 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"io"
+	"math"
+)
+
+var errNoThumbnail = errors.New("no exif thumbnail found")
+
+// Largest thumbnail decoded by readExifThumbnail(), in pixels. The jpeg decoder allocates according to the
+// dimensions in the thumbnail's header, which can claim far more than its few kilobytes of data hold
+const maxThumbnailPixels = 1 << 22
+
+// Largest relative difference between aspect ratios of a thumbnail and its image for thumbnailMatchesImage().
+// This leaves room for thumbnail dimensions being rounded to whole pixels
+const thumbnailAspectTolerance = 0.02
+
+// EXIF tags in IFD1 locating the embedded jpeg thumbnail
+const (
+	exifThumbnailOffset = 0x0201
+	exifThumbnailLength = 0x0202
+)
+
+/*
+This function looks for the APP1 Exif segment at the start of a jpeg and decodes the jpeg thumbnail
+referenced by its second IFD. Returns errNoThumbnail if the input isn't a jpeg or there's no thumbnail
+*/
+func readExifThumbnail(r io.ReaderAt) (image.Image, error) {
+
+	marker := make([]byte, 4)
+	if _, err := r.ReadAt(marker[:2], 0); err != nil || marker[0] != 0xFF || marker[1] != 0xD8 {
+		return nil, errNoThumbnail
+	}
+
+	// Walk through jpeg segments until the Exif segment or the image data is reached
+	offset := int64(2)
+	for {
+		if _, err := r.ReadAt(marker, offset); err != nil || marker[0] != 0xFF {
+			return nil, errNoThumbnail
+		}
+
+		// Start of scan, after which there are no more metadata segments
+		if marker[1] == 0xDA {
+			return nil, errNoThumbnail
+		}
+
+		// Length includes its own 2 bytes
+		segmentLength := int64(binary.BigEndian.Uint16(marker[2:4]))
+		if segmentLength < 2 {
+			return nil, errNoThumbnail
+		}
+
+		if marker[1] == 0xE1 {
+			segment := make([]byte, segmentLength-2)
+			if _, err := r.ReadAt(segment, offset+4); err != nil {
+				return nil, errNoThumbnail
+			}
+
+			if len(segment) > 14 && string(segment[:6]) == "Exif\x00\x00" {
+				return decodeExifThumbnail(segment[6:])
+			}
+		}
+
+		offset += 2 + segmentLength
+	}
+}
+
+// Decodes the thumbnail from the tiff structured data of an Exif segment
+func decodeExifThumbnail(tiffData []byte) (image.Image, error) {
+
+	if !isTiff(tiffData) {
+		return nil, errNoThumbnail
+	}
+
+	var byteOrder binary.ByteOrder = binary.LittleEndian
+	if tiffData[0] == 'M' {
+		byteOrder = binary.BigEndian
+	}
+
+	r := bytes.NewReader(tiffData)
+
+	// IFD0 describes the main image, IFD1 the thumbnail
+	_, ifd1Offset, err := readTiffTags(r, byteOrder, int64(byteOrder.Uint32(tiffData[4:8])))
+	if err != nil || ifd1Offset == 0 {
+		return nil, errNoThumbnail
+	}
+
+	tags, _, err := readTiffTags(r, byteOrder, ifd1Offset)
+	if err != nil {
+		return nil, errNoThumbnail
+	}
+
+	if len(tags[exifThumbnailOffset]) == 0 || len(tags[exifThumbnailLength]) == 0 {
+		return nil, errNoThumbnail
+	}
+
+	start := int(tags[exifThumbnailOffset][0])
+	end := start + int(tags[exifThumbnailLength][0])
+	if end > len(tiffData) || start >= end {
+		return nil, errNoThumbnail
+	}
+
+	config, err := jpeg.DecodeConfig(bytes.NewReader(tiffData[start:end]))
+	if err != nil || config.Width*config.Height > maxThumbnailPixels {
+		return nil, errNoThumbnail
+	}
+
+	return jpeg.Decode(bytes.NewReader(tiffData[start:end]))
+}
+
+/*
+Returns true if the thumbnail has the same aspect ratio as the jpeg read from r. Thumbnails often have a fixed
+size like 160x120 regardless of the photo, with the photo letterboxed inside, so a portrait photo could otherwise
+be converted as a landscape thumbnail with black bars
+*/
+func thumbnailMatchesImage(thumbnail image.Image, r io.ReaderAt) bool {
+
+	config, err := jpeg.DecodeConfig(io.NewSectionReader(r, 0, math.MaxInt64))
+	if err != nil {
+		return false
+	}
+
+	return aspectRatiosMatch(thumbnail.Bounds().Dx(), thumbnail.Bounds().Dy(), config.Width, config.Height)
+}
+
+// Returns true if aspect ratios of both sizes are within thumbnailAspectTolerance of each other
+func aspectRatiosMatch(width1, height1, width2, height2 int) bool {
+	if width1 < 1 || height1 < 1 || width2 < 1 || height2 < 1 {
+		return false
+	}
+
+	ratio1 := float64(width1) / float64(height1)
+	ratio2 := float64(width2) / float64(height2)
+
+	return math.Abs(ratio1-ratio2) <= thumbnailAspectTolerance*ratio2
+}
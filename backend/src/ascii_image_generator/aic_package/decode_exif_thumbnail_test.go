@@ -0,0 +1,111 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"testing"
+)
+
+func encodeTestJpeg(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// Returns a jpeg of width x height with an Exif segment embedding thumbnail through IFD1
+func jpegWithThumbnail(t *testing.T, width, height int, thumbnail []byte) []byte {
+
+	// Little endian tiff header, an empty IFD0 pointing to IFD1, and IFD1 with the thumbnail's offset and length
+	var tiff bytes.Buffer
+	tiff.WriteString("II*\x00")
+	binary.Write(&tiff, binary.LittleEndian, uint32(8))
+	binary.Write(&tiff, binary.LittleEndian, uint16(0))
+	binary.Write(&tiff, binary.LittleEndian, uint32(14))
+
+	thumbnailOffset := uint32(14 + 2 + 2*12 + 4)
+	binary.Write(&tiff, binary.LittleEndian, uint16(2))
+	for _, entry := range [][2]uint32{{exifThumbnailOffset, thumbnailOffset}, {exifThumbnailLength, uint32(len(thumbnail))}} {
+		binary.Write(&tiff, binary.LittleEndian, uint16(entry[0]))
+		binary.Write(&tiff, binary.LittleEndian, uint16(4))
+		binary.Write(&tiff, binary.LittleEndian, uint32(1))
+		binary.Write(&tiff, binary.LittleEndian, entry[1])
+	}
+	binary.Write(&tiff, binary.LittleEndian, uint32(0))
+	tiff.Write(thumbnail)
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+
+	main := encodeTestJpeg(t, width, height)
+
+	var buf bytes.Buffer
+	buf.Write(main[:2])
+	buf.Write([]byte{0xFF, 0xE1})
+	binary.Write(&buf, binary.BigEndian, uint16(len(segment)+2))
+	buf.Write(segment)
+	buf.Write(main[2:])
+	return buf.Bytes()
+}
+
+func TestExifThumbnailAspectRatio(t *testing.T) {
+	thumbnail := encodeTestJpeg(t, 160, 120)
+
+	tests := []struct {
+		name          string
+		width, height int
+		want          bool
+	}{
+		{"same aspect ratio", 400, 300, true},
+		{"rounded thumbnail size", 401, 300, true},
+		{"portrait photo", 300, 400, false},
+		{"wide photo", 640, 360, false},
+	}
+
+	for _, test := range tests {
+		data := jpegWithThumbnail(t, test.width, test.height, thumbnail)
+
+		decoded, err := readExifThumbnail(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		if decoded.Bounds().Dx() != 160 || decoded.Bounds().Dy() != 120 {
+			t.Fatalf("%v: got %v thumbnail, want 160x120", test.name, decoded.Bounds().Size())
+		}
+
+		if got := thumbnailMatchesImage(decoded, bytes.NewReader(data)); got != test.want {
+			t.Errorf("%v: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestExifThumbnailTooLarge(t *testing.T) {
+	thumbnail := encodeTestJpeg(t, 8, 8)
+
+	// Claim 60000x60000 pixels in the thumbnail's SOF0 header, while its data stays 8x8
+	sof := bytes.Index(thumbnail, []byte{0xFF, 0xC0})
+	binary.BigEndian.PutUint16(thumbnail[sof+5:], 60000)
+	binary.BigEndian.PutUint16(thumbnail[sof+7:], 60000)
+
+	if _, err := readExifThumbnail(bytes.NewReader(jpegWithThumbnail(t, 8, 8, thumbnail))); err != errNoThumbnail {
+		t.Errorf("got error %v, want %v", err, errNoThumbnail)
+	}
+}
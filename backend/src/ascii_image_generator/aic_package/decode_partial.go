@@ -0,0 +1,263 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+)
+
+// Returned by decodePartialImage() if the data isn't a jpeg or png it can recover rows from
+var errPartialUnsupported = errors.New("partial decoding isn't supported for this image")
+
+// Number of bytes cut off the end of a truncated jpeg for the second decode in decodePartialJpeg()
+const partialJpegCut = 256
+
+/*
+This function decodes the rows at the start of a truncated baseline jpeg or non-interlaced png. Rows that
+couldn't be recovered are left transparent, so they're filled with Flags.BlendAlphaAgainst during conversion.
+Returns the decoded image along with the number of recovered rows
+*/
+func decodePartialImage(data []byte) (image.Image, int, error) {
+
+	var (
+		img       image.Image
+		validRows int
+		err       error
+	)
+
+	if len(data) > 2 && data[0] == 0xFF && data[1] == 0xD8 {
+		img, validRows, err = decodePartialJpeg(data)
+	} else if len(data) > 8 && string(data[:8]) == "\x89PNG\r\n\x1a\n" {
+		img, validRows, err = decodePartialPng(data)
+	} else {
+		return nil, 0, errPartialUnsupported
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	b := img.Bounds()
+	if validRows < 1 {
+		return nil, 0, errors.New("no rows could be recovered")
+	}
+
+	partial := image.NewNRGBA(b)
+	draw.Draw(partial, image.Rect(b.Min.X, b.Min.Y, b.Max.X, b.Min.Y+validRows), img, b.Min, draw.Src)
+
+	return partial, validRows, nil
+}
+
+/*
+The jpeg decoder can't return an incomplete image, so the missing scan data is replaced with zeros, which
+always decode to valid huffman codes. To find where the real data ends, the jpeg is decoded again with
+partialJpegCut more bytes cut off, and only rows that are identical in both decodes are kept
+*/
+func decodePartialJpeg(data []byte) (image.Image, int, error) {
+
+	config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cut := partialJpegCut
+	if cut > len(data)/4 {
+		cut = len(data) / 4
+	}
+
+	img, err := decodePaddedJpeg(data, config)
+	if err != nil {
+		return nil, 0, err
+	}
+	shorterImg, err := decodePaddedJpeg(data[:len(data)-cut], config)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	b := img.Bounds()
+	validRows := 0
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.At(x, y) != shorterImg.At(x, y) {
+				return img, validRows, nil
+			}
+		}
+		validRows++
+	}
+
+	return img, validRows, nil
+}
+
+// Decodes data followed by zeros and an end of image marker
+func decodePaddedJpeg(data []byte, config image.Config) (image.Image, error) {
+
+	// Enough zeros for the remaining blocks of any jpeg, since they're generated on demand
+	padding := int64(config.Width) * int64(config.Height) * 16
+
+	return jpeg.Decode(io.MultiReader(
+		bytes.NewReader(data),
+		io.LimitReader(zeroReader{}, padding),
+		bytes.NewReader([]byte{0xFF, 0xD9}),
+	))
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+/*
+Inflates as much of the png image data as is present, pads the remaining rows with zeros and decodes a png
+rebuilt from the original header chunks and the padded data. The inflater only returns data once its
+window fills up, so up to the last 32KB of decompressed rows before the truncation are lost
+*/
+func decodePartialPng(data []byte) (image.Image, int, error) {
+
+	var (
+		rebuilt  bytes.Buffer
+		idat     []byte
+		ihdr     []byte
+		offset   = 8
+		foundEnd bool
+	)
+
+	rebuilt.Write(data[:8])
+
+	// Chunks are 4 bytes length, 4 bytes type, data and 4 bytes crc. The last chunk may be incomplete
+	for offset+8 <= len(data) && !foundEnd {
+		length := int(binary.BigEndian.Uint32(data[offset : offset+4]))
+		chunkType := string(data[offset+4 : offset+8])
+
+		dataEnd := offset + 8 + length
+		if dataEnd > len(data) {
+			dataEnd = len(data)
+		}
+		chunkEnd := dataEnd + 4
+		if chunkEnd > len(data) {
+			chunkEnd = len(data)
+		}
+
+		switch chunkType {
+		case "IHDR":
+			ihdr = data[offset+8 : dataEnd]
+			rebuilt.Write(data[offset:chunkEnd])
+		case "IDAT":
+			idat = append(idat, data[offset+8:dataEnd]...)
+		case "IEND":
+			foundEnd = true
+		default:
+			// Ancillary chunks after the image data aren't needed
+			if idat == nil {
+				rebuilt.Write(data[offset:chunkEnd])
+			}
+		}
+
+		offset = chunkEnd
+	}
+
+	if len(ihdr) < 13 || len(idat) == 0 {
+		return nil, 0, errPartialUnsupported
+	}
+
+	imgWidth := int(binary.BigEndian.Uint32(ihdr[0:4]))
+	imgHeight := int(binary.BigEndian.Uint32(ihdr[4:8]))
+	bitDepth := int(ihdr[8])
+	interlaced := ihdr[12] != 0
+
+	var channels int
+	switch ihdr[9] {
+	case 0, 3:
+		channels = 1
+	case 4:
+		channels = 2
+	case 2:
+		channels = 3
+	case 6:
+		channels = 4
+	default:
+		return nil, 0, errPartialUnsupported
+	}
+
+	if interlaced || imgWidth < 1 || imgHeight < 1 {
+		return nil, 0, errPartialUnsupported
+	}
+
+	// Each row starts with a filter type byte
+	rowBytes := (imgWidth*channels*bitDepth+7)/8 + 1
+
+	zlibReader, err := zlib.NewReader(bytes.NewReader(idat))
+	if err != nil {
+		return nil, 0, err
+	}
+	rawData, _ := ioutil.ReadAll(io.LimitReader(zlibReader, int64(rowBytes)*int64(imgHeight)))
+
+	validRows := len(rawData) / rowBytes
+	if validRows < 1 {
+		return nil, 0, errors.New("no rows could be recovered")
+	}
+
+	paddedData := make([]byte, rowBytes*imgHeight)
+	copy(paddedData, rawData[:validRows*rowBytes])
+
+	var compressed bytes.Buffer
+	zlibWriter := zlib.NewWriter(&compressed)
+	zlibWriter.Write(paddedData)
+	zlibWriter.Close()
+
+	writePngChunk(&rebuilt, "IDAT", compressed.Bytes())
+	writePngChunk(&rebuilt, "IEND", nil)
+
+	img, err := png.Decode(&rebuilt)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return img, validRows, nil
+}
+
+// Writes a png chunk with its length and crc
+func writePngChunk(w *bytes.Buffer, chunkType string, chunkData []byte) {
+
+	lengthBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(lengthBytes, uint32(len(chunkData)))
+	w.Write(lengthBytes)
+
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(chunkType))
+	crc.Write(chunkData)
+
+	w.WriteString(chunkType)
+	w.Write(chunkData)
+
+	crcBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(crcBytes, crc.Sum32())
+	w.Write(crcBytes)
+}
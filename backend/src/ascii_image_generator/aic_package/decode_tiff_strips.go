@@ -0,0 +1,272 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Longest side of the image returned by decodeTiffStrips(). This is always larger than any practical
+// ascii art dimensions, so the downsampling doesn't affect ascii art quality
+const streamDecodeMaxSide = 2048
+
+// Largest number of values read for a single tiff tag. Counts come straight from the file, so they're capped
+// before anything is allocated for them. This allows a million strips, far more than any practical tiff has
+const maxTiffTagValues = 1 << 20
+
+// Largest row read by decodeTiffStrips(), in bytes. Rows of 16 million RGBA pixels still fit
+const maxTiffRowBytes = 1 << 26
+
+// Returned by decodeTiffStrips() if the tiff can't be read strip by strip, in which case it's decoded fully instead
+var errStreamUnsupported = errors.New("tiff layout isn't supported for stream decoding")
+
+// TIFF tags needed to read baseline uncompressed strips
+const (
+	tiffImageWidth      = 256
+	tiffImageLength     = 257
+	tiffBitsPerSample   = 258
+	tiffCompression     = 259
+	tiffPhotometric     = 262
+	tiffStripOffsets    = 273
+	tiffSamplesPerPixel = 277
+	tiffRowsPerStrip    = 278
+	tiffPlanarConfig    = 284
+)
+
+// Returns true if data starts with either little or big endian tiff header
+func isTiff(header []byte) bool {
+	return len(header) >= 4 && (string(header[:4]) == "II*\x00" || string(header[:4]) == "MM\x00*")
+}
+
+/*
+This function reads an uncompressed, 8 bits per sample, strip based tiff one row at a time and averages
+rows and columns into an image whose longest side is at most streamDecodeMaxSide, so that the full image
+is never held in memory. Grayscale, RGB and RGBA tiffs are supported.
+
+Compressed, tiled or planar tiffs return errStreamUnsupported
+*/
+func decodeTiffStrips(r io.ReaderAt) (image.Image, error) {
+
+	header := make([]byte, 8)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	if !isTiff(header) {
+		return nil, errStreamUnsupported
+	}
+
+	var byteOrder binary.ByteOrder = binary.LittleEndian
+	if header[0] == 'M' {
+		byteOrder = binary.BigEndian
+	}
+
+	tags, _, err := readTiffTags(r, byteOrder, int64(byteOrder.Uint32(header[4:8])))
+	if err != nil {
+		return nil, err
+	}
+
+	tagValue := func(tag uint16, defaultValue uint32) uint32 {
+		if values, ok := tags[tag]; ok && len(values) > 0 {
+			return values[0]
+		}
+		return defaultValue
+	}
+
+	imgWidth := int(tagValue(tiffImageWidth, 0))
+	imgHeight := int(tagValue(tiffImageLength, 0))
+	samples := int(tagValue(tiffSamplesPerPixel, 1))
+	photometric := tagValue(tiffPhotometric, 1)
+	rowsPerStrip := int(tagValue(tiffRowsPerStrip, uint32(imgHeight)))
+	stripOffsets := tags[tiffStripOffsets]
+
+	if imgWidth < 1 || imgHeight < 1 || rowsPerStrip < 1 || len(stripOffsets) == 0 {
+		return nil, errStreamUnsupported
+	}
+	if tagValue(tiffCompression, 1) != 1 || tagValue(tiffPlanarConfig, 1) != 1 {
+		return nil, errStreamUnsupported
+	}
+	// BitsPerSample defaults to 1 when it's missing, which isn't supported
+	bitsPerSample, ok := tags[tiffBitsPerSample]
+	if !ok {
+		return nil, errStreamUnsupported
+	}
+	for _, bits := range bitsPerSample {
+		if bits != 8 {
+			return nil, errStreamUnsupported
+		}
+	}
+	if !(samples == 1 && photometric <= 1) && !((samples == 3 || samples == 4) && photometric == 2) {
+		return nil, errStreamUnsupported
+	}
+	if imgWidth*samples > maxTiffRowBytes {
+		return nil, errStreamUnsupported
+	}
+
+	// Number of source pixels averaged into each pixel of returned image, along each axis
+	factor := 1
+	for imgWidth/factor > streamDecodeMaxSide || imgHeight/factor > streamDecodeMaxSide {
+		factor++
+	}
+
+	outWidth := (imgWidth + factor - 1) / factor
+	outHeight := (imgHeight + factor - 1) / factor
+	outImg := image.NewNRGBA(image.Rect(0, 0, outWidth, outHeight))
+
+	var (
+		row       = make([]byte, imgWidth*samples)
+		sums      = make([]uint64, outWidth*4)
+		counts    = make([]uint64, outWidth)
+		outY      = 0
+		rowsInOut = 0
+	)
+
+	// Writes averaged values of current output row into outImg and resets them
+	flushRow := func() {
+		for x := 0; x < outWidth; x++ {
+			if counts[x] == 0 {
+				continue
+			}
+			outImg.SetNRGBA(x, outY, color.NRGBA{
+				R: uint8(sums[x*4] / counts[x]),
+				G: uint8(sums[x*4+1] / counts[x]),
+				B: uint8(sums[x*4+2] / counts[x]),
+				A: uint8(sums[x*4+3] / counts[x]),
+			})
+		}
+		for i := range sums {
+			sums[i] = 0
+		}
+		for i := range counts {
+			counts[i] = 0
+		}
+		outY++
+		rowsInOut = 0
+	}
+
+	for y := 0; y < imgHeight; y++ {
+
+		strip := y / rowsPerStrip
+		if strip >= len(stripOffsets) {
+			return nil, errStreamUnsupported
+		}
+
+		offset := int64(stripOffsets[strip]) + int64(y%rowsPerStrip)*int64(len(row))
+		if _, err := r.ReadAt(row, offset); err != nil {
+			return nil, err
+		}
+
+		for x := 0; x < imgWidth; x++ {
+			var red, green, blue, alpha uint8 = 0, 0, 0, 255
+
+			pixel := row[x*samples : (x+1)*samples]
+			switch samples {
+			case 1:
+				red = pixel[0]
+				if photometric == 0 {
+					// WhiteIsZero
+					red = 255 - red
+				}
+				green, blue = red, red
+			case 3:
+				red, green, blue = pixel[0], pixel[1], pixel[2]
+			case 4:
+				red, green, blue, alpha = pixel[0], pixel[1], pixel[2], pixel[3]
+			}
+
+			outX := x / factor
+			sums[outX*4] += uint64(red)
+			sums[outX*4+1] += uint64(green)
+			sums[outX*4+2] += uint64(blue)
+			sums[outX*4+3] += uint64(alpha)
+			counts[outX]++
+		}
+
+		rowsInOut++
+		if rowsInOut == factor || y == imgHeight-1 {
+			flushRow()
+		}
+	}
+
+	return outImg, nil
+}
+
+// Reads the tiff IFD at ifdOffset and returns its SHORT and LONG tag values, along with the offset of the next IFD.
+// Returns errStreamUnsupported if a tag has more than maxTiffTagValues values
+func readTiffTags(r io.ReaderAt, byteOrder binary.ByteOrder, ifdOffset int64) (map[uint16][]uint32, int64, error) {
+
+	countBytes := make([]byte, 2)
+	if _, err := r.ReadAt(countBytes, ifdOffset); err != nil {
+		return nil, 0, err
+	}
+
+	// Entries are followed by 4 bytes for the offset of next IFD
+	entries := make([]byte, 12*int(byteOrder.Uint16(countBytes))+4)
+	if _, err := r.ReadAt(entries, ifdOffset+2); err != nil {
+		return nil, 0, err
+	}
+	nextIFDOffset := int64(byteOrder.Uint32(entries[len(entries)-4:]))
+	entries = entries[:len(entries)-4]
+
+	tags := map[uint16][]uint32{}
+
+	for i := 0; i < len(entries); i += 12 {
+		entry := entries[i : i+12]
+
+		tag := byteOrder.Uint16(entry[0:2])
+		dataType := byteOrder.Uint16(entry[2:4])
+		count := int(byteOrder.Uint32(entry[4:8]))
+
+		var size int
+		switch dataType {
+		case 3: // SHORT
+			size = 2
+		case 4: // LONG
+			size = 4
+		default:
+			continue
+		}
+
+		if count > maxTiffTagValues {
+			return nil, 0, errStreamUnsupported
+		}
+
+		// Values are stored in the entry itself if they fit in 4 bytes, otherwise the entry holds their offset
+		data := entry[8:12]
+		if count*size > 4 {
+			data = make([]byte, count*size)
+			if _, err := r.ReadAt(data, int64(byteOrder.Uint32(entry[8:12]))); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		values := make([]uint32, count)
+		for j := 0; j < count; j++ {
+			if size == 2 {
+				values[j] = uint32(byteOrder.Uint16(data[j*2 : j*2+2]))
+			} else {
+				values[j] = byteOrder.Uint32(data[j*4 : j*4+4])
+			}
+		}
+		tags[tag] = values
+	}
+
+	return tags, nextIFDOffset, nil
+}
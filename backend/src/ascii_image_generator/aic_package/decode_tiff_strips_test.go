@@ -0,0 +1,107 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// Entry of the IFD written by buildTiff(), with a single LONG value or offset
+type tiffTestEntry struct {
+	tag, dataType uint16
+	count, value  uint32
+}
+
+// Returns a little endian tiff whose only IFD holds entries, followed by pixels
+func buildTiff(entries []tiffTestEntry, pixels []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("II*\x00")
+	binary.Write(&buf, binary.LittleEndian, uint32(8))
+
+	binary.Write(&buf, binary.LittleEndian, uint16(len(entries)))
+	for _, entry := range entries {
+		binary.Write(&buf, binary.LittleEndian, entry.tag)
+		binary.Write(&buf, binary.LittleEndian, entry.dataType)
+		binary.Write(&buf, binary.LittleEndian, entry.count)
+		binary.Write(&buf, binary.LittleEndian, entry.value)
+	}
+	binary.Write(&buf, binary.LittleEndian, uint32(0))
+
+	buf.Write(pixels)
+	return buf.Bytes()
+}
+
+// Offset of the pixels passed to buildTiff() along with entries
+func pixelOffset(entries []tiffTestEntry) uint32 {
+	return uint32(8 + 2 + 12*len(entries) + 4)
+}
+
+// Entries of a 2x2 8-bit grayscale tiff with a single strip, with BitsPerSample left out if bits is 0
+func grayTiffEntries(bits uint32) []tiffTestEntry {
+	entries := []tiffTestEntry{
+		{tiffImageWidth, 4, 1, 2},
+		{tiffImageLength, 4, 1, 2},
+		{tiffPhotometric, 3, 1, 1},
+		{tiffStripOffsets, 4, 1, 0},
+	}
+	if bits != 0 {
+		entries = append(entries, tiffTestEntry{tiffBitsPerSample, 3, 1, bits})
+	}
+	entries[3].value = pixelOffset(entries)
+	return entries
+}
+
+func TestDecodeTiffStrips(t *testing.T) {
+	pixels := []byte{0, 85, 170, 255}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{"8 bits", buildTiff(grayTiffEntries(8), pixels), nil},
+		{"missing bits per sample", buildTiff(grayTiffEntries(0), pixels), errStreamUnsupported},
+		{"1 bit", buildTiff(grayTiffEntries(1), pixels), errStreamUnsupported},
+		{"huge tag count", buildTiff([]tiffTestEntry{{tiffStripOffsets, 4, 0xffffffff, 0}}, nil), errStreamUnsupported},
+		{"huge width", buildTiff([]tiffTestEntry{
+			{tiffImageWidth, 4, 1, 0xffffffff},
+			{tiffImageLength, 4, 1, 1},
+			{tiffBitsPerSample, 3, 1, 8},
+			{tiffStripOffsets, 4, 1, 8},
+		}, nil), errStreamUnsupported},
+	}
+
+	for _, test := range tests {
+		img, err := decodeTiffStrips(bytes.NewReader(test.data))
+		if err != test.wantErr {
+			t.Errorf("%v: got error %v, want %v", test.name, err, test.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		for i, want := range pixels {
+			r, _, _, _ := img.At(i%2, i/2).RGBA()
+			if uint8(r>>8) != want {
+				t.Errorf("%v: pixel %v is %v, want %v", test.name, i, r>>8, want)
+			}
+		}
+	}
+}
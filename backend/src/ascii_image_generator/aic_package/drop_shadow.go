@@ -0,0 +1,73 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"image/color"
+	"math"
+
+	imgManip "github.com/TheZoraiz/ascii-image-converter/image_manipulation"
+	"github.com/disintegration/imaging"
+	"github.com/fogleman/gg"
+)
+
+// Standard deviation of the gaussian blur of Flags.DropShadow, in pixels
+const shadowBlurSigma = 2.0
+
+/*
+Returns how many pixels saved images need on their left, top, right and bottom, beyond their 5 pixels of
+padding, for the shadow of Flags.DropShadow to fit along with its blur. All of them are 0 if it's off
+*/
+func getShadowMargins() (int, int, int, int) {
+	if !dropShadow {
+		return 0, 0, 0, 0
+	}
+
+	// A gaussian blur fades out within 3 standard deviations
+	blur := int(math.Ceil(shadowBlurSigma * 3))
+
+	margin := func(offset int) int {
+		if offset+blur > 5 {
+			return offset + blur - 5
+		}
+		return 0
+	}
+
+	return margin(-shadowOffset[0]), margin(-shadowOffset[1]), margin(shadowOffset[0]), margin(shadowOffset[1])
+}
+
+/*
+Draws the shadow of Flags.DropShadow on dc. drawGlyphs is called to draw the characters again in the
+shadow color, on a transparent layer of the same size, which is then blurred and placed on dc at the
+shadow offset
+*/
+func drawDropShadow(dc *gg.Context, drawGlyphs func(*gg.Context, func(imgManip.AsciiChar) color.Color)) {
+
+	shadowColor := color.NRGBA{
+		uint8(shadowRGBA[0]),
+		uint8(shadowRGBA[1]),
+		uint8(shadowRGBA[2]),
+		uint8(math.Round(float64(shadowRGBA[3]) / 100 * 255)),
+	}
+
+	layer := gg.NewContext(dc.Width(), dc.Height())
+	drawGlyphs(layer, func(imgManip.AsciiChar) color.Color {
+		return shadowColor
+	})
+
+	dc.DrawImage(imaging.Blur(layer.Image(), shadowBlurSigma), shadowOffset[0], shadowOffset[1])
+}
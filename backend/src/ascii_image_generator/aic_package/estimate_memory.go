@@ -0,0 +1,283 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bufio"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"runtime"
+	"unsafe"
+
+	imgManip "github.com/TheZoraiz/ascii-image-converter/image_manipulation"
+)
+
+// Bytes each character of ascii art is estimated to take in its strings, beyond imgManip.AsciiChar itself
+const (
+	estimatedCharBytes        = 8
+	estimatedColoredCharBytes = 40
+)
+
+/*
+EstimateMemory() takes the same arguments as Convert() and returns an estimate of the peak memory in bytes
+that converting filePath with flags takes, without decoding its pixels. Only the header of the image is read,
+along with the block structure of gifs to count their frames, so this is cheap enough to call before accepting
+a conversion job.
+
+This is an estimate, not a bound. It models the largest buffers that conversion holds at once: the input
+data of urls and piped input, the decoded image and its Flags.DecodeColorModel copy, the resized image and
+the pixels and characters made from it, and images drawn for Flags.SaveImagePath and Flags.SaveGifPath. For
+gifs, every composited frame and its ascii art is held at once, and one frame per CPU is resized at a time.
+Streamed tiffs through Flags.StreamDecode are assumed to be downsampled while decoding. Embedded thumbnails,
+scroll animations and Flags.TargetFPS aren't accounted for.
+
+The estimate counts live data only. Go's garbage collector lets the heap grow past live data before
+collecting, up to twice as much with the default GOGC, so memory limits should leave room for that
+*/
+func EstimateMemory(filePath string, flags Flags) (int64, error) {
+
+	if err := setFlags(flags); err != nil {
+		return 0, err
+	}
+
+	input, err := readInput(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer input.close()
+
+	config, format, err := image.DecodeConfig(io.NewSectionReader(input.readerAt(), 0, math.MaxInt64))
+	if err != nil {
+		return 0, err
+	}
+
+	cols, rows, err := imgManip.GetAsciiDimensions(config.Width, config.Height, full, dimensions, width, height, [2]int{termWidth, termHeight})
+	if err != nil {
+		return 0, err
+	}
+
+	cellWidth, cellHeight := 1, 1
+	if braille {
+		cellWidth, cellHeight = 2, 4
+	} else if dotMode == "legacy2x2" {
+		cellWidth, cellHeight = 2, 2
+	}
+
+	var (
+		imgWidth  = int64(config.Width)
+		imgHeight = int64(config.Height)
+		cells     = int64(cols) * int64(rows)
+
+		resizedWidth  = int64(cols * cellWidth)
+		resizedHeight = int64(rows * cellHeight)
+	)
+
+	// Input data of urls and piped input is held for the whole conversion
+	estimate := int64(len(input.urlImgBytes) + len(input.pipedInputBytes))
+
+	decodedBytes := imgWidth * imgHeight * bytesPerPixel(config.ColorModel)
+	if streamDecode && format == "tiff" {
+		decodedBytes = streamedImageBytes(imgWidth, imgHeight)
+	} else if !decodedModelMatches(config.ColorModel) {
+		decodedBytes += imgWidth * imgHeight * 4
+	}
+
+	// Resizing goes through an intermediate image of the resized width and original height
+	conversionBytes := resizedWidth*imgHeight*4 +
+		resizedWidth*resizedHeight*4 +
+		resizedWidth*resizedHeight*int64(unsafe.Sizeof(imgManip.AsciiPixel{}))
+
+	charBytes := int64(unsafe.Sizeof(imgManip.AsciiChar{})) + estimatedCharBytes
+	if colored || grayscale || fontColor != [3]int{255, 255, 255} {
+		charBytes += estimatedColoredCharBytes
+	}
+
+	// Characters, along with the ascii art string made from them
+	asciiBytes := cells * charBytes * 2
+
+	if !inputIsGif || forceStatic {
+		estimate += decodedBytes + conversionBytes + asciiBytes
+
+		if saveImagePath != "" {
+			savedWidth := int64(float64(cols)*savedImageCharWidth) + 10
+			savedHeight := int64(float64(rows)*savedImageCharWidth*2) + 10
+
+			// The drawing canvas, along with a copy while encoding
+			estimate += savedWidth * savedHeight * 4 * 2
+		}
+
+		return estimate, nil
+	}
+
+	frames, err := countGifFrames(io.NewSectionReader(input.readerAt(), 0, math.MaxInt64))
+	if err != nil {
+		return 0, err
+	}
+
+	concurrentFrames := int64(runtime.NumCPU())
+	if concurrentFrames > frames {
+		concurrentFrames = frames
+	}
+
+	frameBytes := imgWidth * imgHeight
+
+	// Paletted frames as decoded, composited frames and the ascii art of each frame are all held at once
+	estimate += frames*frameBytes + frames*frameBytes*4 + frames*asciiBytes
+	estimate += concurrentFrames * (frameBytes*4 + conversionBytes)
+
+	if saveGifPath != "" {
+		savedFrameBytes := (imgWidth + 10) * (imgHeight + 10)
+
+		// Paletted frames of the saved gif, along with the frames being drawn
+		estimate += frames*savedFrameBytes + concurrentFrames*savedFrameBytes*4*2
+	}
+
+	return estimate, nil
+}
+
+// Returns the estimated bytes per pixel of images decoded with model
+func bytesPerPixel(model color.Model) int64 {
+	switch model {
+	case color.GrayModel:
+		return 1
+	case color.Gray16Model:
+		return 2
+	case color.YCbCrModel:
+		// Chroma is usually subsampled, so this is between 4:2:0 and 4:4:4
+		return 2
+	case color.CMYKModel, color.RGBAModel, color.NRGBAModel:
+		return 4
+	case color.RGBA64Model, color.NRGBA64Model:
+		return 8
+	}
+
+	// Paletted images have their palette as a color model
+	if _, ok := model.(color.Palette); ok {
+		return 1
+	}
+	return 4
+}
+
+// Returns true if images decoded with model are already in the color model of Flags.DecodeColorModel
+func decodedModelMatches(model color.Model) bool {
+	return (decodeModel == "rgba" && model == color.RGBAModel) ||
+		(decodeModel == "nrgba" && model == color.NRGBAModel) ||
+		decodeModel == ""
+}
+
+// Returns the bytes of the image decodeTiffStrips() returns for a tiff of imgWidth x imgHeight
+func streamedImageBytes(imgWidth, imgHeight int64) int64 {
+
+	longestSide := imgWidth
+	if imgHeight > longestSide {
+		longestSide = imgHeight
+	}
+
+	scale := 1.0
+	if longestSide > streamDecodeMaxSide {
+		scale = float64(streamDecodeMaxSide) / float64(longestSide)
+	}
+
+	return int64(math.Ceil(float64(imgWidth)*scale)) * int64(math.Ceil(float64(imgHeight)*scale)) * 4
+}
+
+/*
+Counts the frames of the gif read from r by walking its blocks, skipping image data without decompressing
+it. Returns an error if r doesn't hold a gif or ends before its trailer
+*/
+func countGifFrames(r io.Reader) (int64, error) {
+
+	reader := bufio.NewReader(r)
+
+	// Header and logical screen descriptor
+	header := make([]byte, 13)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return 0, err
+	}
+	if string(header[:3]) != "GIF" {
+		return 0, errors.New("not a gif")
+	}
+
+	// Global color table, if its flag is set
+	if header[10]&0x80 != 0 {
+		if _, err := reader.Discard(3 << (header[10]&0x07 + 1)); err != nil {
+			return 0, err
+		}
+	}
+
+	var frames int64
+
+	for {
+		blockType, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		switch blockType {
+		case 0x21:
+			// Extension label, followed by data sub-blocks
+			if _, err := reader.Discard(1); err != nil {
+				return 0, err
+			}
+		case 0x2C:
+			frames++
+
+			// Image descriptor, along with the local color table if its flag is set
+			descriptor := make([]byte, 9)
+			if _, err := io.ReadFull(reader, descriptor); err != nil {
+				return 0, err
+			}
+			if descriptor[8]&0x80 != 0 {
+				if _, err := reader.Discard(3 << (descriptor[8]&0x07 + 1)); err != nil {
+					return 0, err
+				}
+			}
+
+			// Minimum code size of the image data, followed by data sub-blocks
+			if _, err := reader.Discard(1); err != nil {
+				return 0, err
+			}
+		case 0x3B:
+			return frames, nil
+		default:
+			return 0, errors.New("invalid gif block")
+		}
+
+		if err := skipGifSubBlocks(reader); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Skips data sub-blocks of a gif, each one starting with its size, until the terminating empty sub-block
+func skipGifSubBlocks(reader *bufio.Reader) error {
+	for {
+		size, err := reader.ReadByte()
+		if err != nil {
+			return err
+		}
+		if size == 0 {
+			return nil
+		}
+		if _, err := reader.Discard(int(size)); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+
+	imgManip "github.com/TheZoraiz/ascii-image-converter/image_manipulation"
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+)
+
+// Layout of the atlas saved by SaveGlyphAtlas(). Each slot holds a character cell of saved
+// images along with space for its coverage label
+const (
+	glyphAtlasColumns    = 16
+	glyphAtlasSlotWidth  = 48
+	glyphAtlasLabelSpace = 16
+)
+
+/*
+SaveGlyphAtlas() takes a directory path as its first argument and a aic_package.Flags literal as the
+second argument, and saves every character that ascii art can be made of with those flags as glyph-atlas.png
+in the directory. Glyphs are rendered with the same font and size as saved ascii art images, each inside a
+gray box showing its character cell, so that glyphs overflowing their cell or missing from the font stand out.
+
+Each glyph is labeled with its coverage, which is the portion of its character cell filled by the
+glyph from 0 to 1. Glyphs are braille characters if Flags.Braille is true, block characters if
+Flags.DotMode is set, and otherwise the character set picked through Flags.CustomMap, Flags.RampPreset,
+Flags.Complex and Flags.MaxGlyphs, ordered from darkest to lightest
+*/
+func SaveGlyphAtlas(savePath string, flags Flags) error {
+
+	if err := setFlags(flags); err != nil {
+		return err
+	}
+
+	if err := loadFont(); err != nil {
+		return err
+	}
+
+	fullPathName, err := getFullSavePath("glyph-atlas.png", savePath)
+	if err != nil {
+		return fmt.Errorf("can't save file: %v", err)
+	}
+
+	glyphs := getAtlasGlyphs()
+
+	cellWidth := int(savedImageCharWidth)
+	cellHeight := cellWidth * 2
+	slotHeight := cellHeight + glyphAtlasLabelSpace
+
+	columns := glyphAtlasColumns
+	if len(glyphs) < columns {
+		columns = len(glyphs)
+	}
+	rows := (len(glyphs) + columns - 1) / columns
+
+	fontFace := truetype.NewFace(tempFont, &truetype.Options{Size: savedImageCharWidth * 1.5})
+
+	// Labels are always drawn with the embedded font, since a custom font may lack digits
+	labelFont, _ := truetype.Parse(embeddedHackRegularFont)
+	labelFace := truetype.NewFace(labelFont, &truetype.Options{Size: 9})
+
+	dc := gg.NewContext(columns*glyphAtlasSlotWidth, rows*slotHeight)
+	dc.SetColor(color.Black)
+	dc.Clear()
+
+	for i, glyph := range glyphs {
+
+		slotX := float64((i % columns) * glyphAtlasSlotWidth)
+		slotY := float64((i / columns) * slotHeight)
+		cellX := slotX + float64(glyphAtlasSlotWidth-cellWidth)/2
+
+		dc.SetRGB255(48, 48, 48)
+		dc.DrawRectangle(cellX, slotY, float64(cellWidth), float64(cellHeight))
+		dc.Fill()
+
+		dc.SetFontFace(fontFace)
+		dc.SetColor(color.White)
+		dc.DrawStringWrapped(glyph, cellX, slotY, 0, 0, float64(cellWidth), 1.8, gg.AlignLeft)
+
+		dc.SetFontFace(labelFace)
+		dc.SetRGB255(160, 160, 160)
+		label := fmt.Sprintf("%.3f", getGlyphCoverage(glyph, fontFace, cellWidth, cellHeight))
+		dc.DrawStringAnchored(label, slotX+float64(glyphAtlasSlotWidth)/2, slotY+float64(slotHeight)-4, 0.5, 0)
+	}
+
+	var buf bytes.Buffer
+	if err := dc.EncodePNG(&buf); err != nil {
+		return err
+	}
+
+	return writeSaveFile(fullPathName, buf.Bytes())
+}
+
+// Returns characters that ascii art can consist of with the current flags, as described for SaveGlyphAtlas()
+func getAtlasGlyphs() []string {
+
+	var glyphs []string
+
+	if braille {
+		// Braille characters are ordered by the bitmask of their dots, starting with the blank character
+		for dots := 0; dots < 256; dots++ {
+			glyphs = append(glyphs, string(rune(0x2800+dots)))
+		}
+	} else if dotMode == "legacy2x2" {
+		glyphs = append(glyphs, imgManip.QuadrantChars[:]...)
+		if !separated {
+			for _, shade := range imgManip.LegacyShadeChars {
+				glyphs = append(glyphs, shade.Char)
+			}
+		}
+	} else {
+		charMap := customMap
+		if charMap == "" {
+			charMap = rampPresets[rampPreset]
+		}
+
+		table := imgManip.GetCharacterTable(complex, charMap, maxGlyphs)
+		for i := 0; i < len(table); i++ {
+			glyphs = append(glyphs, table[i])
+		}
+	}
+
+	return glyphs
+}
+
+// Draws glyph alone in a character cell of saved images, and returns the average brightness of the cell from 0 to 1
+func getGlyphCoverage(glyph string, fontFace font.Face, cellWidth, cellHeight int) float64 {
+
+	dc := gg.NewContext(cellWidth, cellHeight)
+	dc.SetColor(color.Black)
+	dc.Clear()
+
+	dc.SetFontFace(fontFace)
+	dc.SetColor(color.White)
+	dc.DrawStringWrapped(glyph, 0, 0, 0, 0, float64(cellWidth), 1.8, gg.AlignLeft)
+
+	img := dc.Image()
+
+	var sum uint64
+	for y := 0; y < cellHeight; y++ {
+		for x := 0; x < cellWidth; x++ {
+			r, _, _, _ := img.At(x, y).RGBA()
+			sum += uint64(r)
+		}
+	}
+
+	return float64(sum) / float64(0xffff*cellWidth*cellHeight)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Named combinations of flags for ApplyPreset(). Each one only sets the fields it's about
+var flagPresets = map[string]func(flags *Flags){
+
+	// Green phosphor monitor, darkened towards the corners
+	"crt": func(flags *Flags) {
+		flags.Colored = false
+		flags.Grayscale = false
+		flags.FontColor = [3]int{51, 255, 102}
+		flags.SaveBackgroundColor = [4]int{0, 20, 0, 100}
+		flags.Vignette = 0.5
+	},
+
+	// Dithered black dots on white paper, like halftone printing
+	"newspaper": func(flags *Flags) {
+		flags.Braille = true
+		flags.Dither = true
+		flags.Colored = false
+		flags.Grayscale = false
+		flags.FontColor = [3]int{0, 0, 0}
+		flags.SaveBackgroundColor = [4]int{255, 255, 255, 100}
+	},
+
+	// Original colors with the detailed character set
+	"color": func(flags *Flags) {
+		flags.Colored = true
+		flags.Complex = true
+		flags.DecodeColorModel = "nrgba"
+	},
+
+	// Original colors with shade block characters
+	"blocks": func(flags *Flags) {
+		flags.Colored = true
+		flags.RampPreset = "shade"
+		flags.CustomMap = ""
+	},
+
+	// Braille with local thresholds, for screenshots and scanned text
+	"document": func(flags *Flags) {
+		flags.Braille = true
+		flags.Dither = false
+		flags.AdaptiveThreshold = true
+		flags.AdaptiveWindow = 15
+	},
+
+	// Edges drawn with line characters over the usual ascii art
+	"sketch": func(flags *Flags) {
+		flags.Colored = false
+		flags.DetailOverlay = true
+		flags.EdgeThreshold = 96
+	},
+}
+
+// Presets() returns the names of the presets accepted by ApplyPreset(), in alphabetical order
+func Presets() []string {
+
+	names := make([]string, 0, len(flagPresets))
+	for name := range flagPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+/*
+ApplyPreset() returns base with the fields of the named preset set over it, e.g. ApplyPreset("crt", DefaultFlags()).
+Fields a preset isn't about are kept from base, so flags like Flags.Dimensions and saving paths can be set either
+before or after applying it. Returns an error if there's no preset with the passed name
+*/
+func ApplyPreset(name string, base Flags) (Flags, error) {
+
+	applyPreset, ok := flagPresets[name]
+	if !ok {
+		return base, fmt.Errorf("invalid preset %v, must be one of %v", name, strings.Join(Presets(), ", "))
+	}
+
+	applyPreset(&base)
+
+	return base, nil
+}
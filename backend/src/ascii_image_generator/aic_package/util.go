@@ -17,13 +17,16 @@ limitations under the License.
 package aic_package
 
 import (
+	"bytes"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	imgManip "github.com/TheZoraiz/ascii-image-converter/image_manipulation"
 )
@@ -46,7 +49,7 @@ func saveAsciiArt(asciiSet [][]imgManip.AsciiChar, imagePath, savePath, urlImgNa
 
 	// If path exists
 	if _, err := os.Stat(savePath); !os.IsNotExist(err) {
-		err := ioutil.WriteFile(savePath+saveFileName, []byte(strings.Join(saveAscii, "\n")), 0666)
+		err := writeSaveFile(savePath+saveFileName, []byte(strings.Join(saveAscii, "\n")))
 		if err != nil {
 			return err
 		} else if onlySave {
@@ -86,33 +89,125 @@ func createSaveFileName(imagePath, urlImgName, label string) (string, error) {
 	return newName + label, nil
 }
 
+// Character placed in blank cells of ascii art, such as ones hidden by Flags.MaskPath
+var blankChar = imgManip.AsciiChar{
+	OriginalColor: " ",
+	SetColor:      " ",
+	Simple:        " ",
+}
+
 // flattenAscii flattens a two-dimensional grid of ascii characters into a one dimension
-// of lines of ascii
+// of lines of ascii, each surrounded by Flags.Indent, Flags.LinePrefix and Flags.LineSuffix.
+// Lines longer than Flags.WrapAt are continued on the following lines
 func flattenAscii(asciiSet [][]imgManip.AsciiChar, colored, toSaveTxt bool) []string {
+	cells := make([][]string, len(asciiSet))
+
+	for y, line := range asciiSet {
+		cells[y] = make([]string, len(line))
+		for x, char := range line {
+			cells[y][x] = flattenChar(char, colored, toSaveTxt)
+		}
+	}
+
+	return flattenCells(cells)
+}
+
+// flattenCells works like flattenAscii(), for characters that are already flattened to their displayed strings
+func flattenCells(cells [][]string) []string {
 	var ascii []string
 
-	for _, line := range asciiSet {
-		var tempAscii string
+	for _, line := range cells {
+		for len(line) > 0 {
+			segment := line
+			if wrapAt > 0 && len(segment) > wrapAt {
+				segment = line[:wrapAt]
+			}
+			line = line[len(segment):]
+
+			ascii = append(ascii, indent+linePrefix+strings.Join(segment, "")+lineSuffix)
+		}
+	}
+
+	return ascii
+}
+
+// Returns the string used to display a single ascii character, depending
+// upon whether it's colored, has a font color or is to be saved as .txt
+func flattenChar(char imgManip.AsciiChar, colored, toSaveTxt bool) string {
+	if toSaveTxt {
+		return char.Simple
+	}
+
+	if colored {
+		return styleRampLevel(char, char.OriginalColor)
+	} else if fontColor != [3]int{255, 255, 255} {
+		return styleRampLevel(char, char.SetColor)
+	}
+	return styleRampLevel(char, char.Simple)
+}
+
+// Surrounds the displayed string of char with the blink or conceal codes of Flags.BlinkBrightest
+// and Flags.ConcealDarkest. Each one is turned off right after char, so that it can't leak further
+func styleRampLevel(char imgManip.AsciiChar, display string) string {
+	if brightestIndex < 1 {
+		return display
+	}
 
-		for _, char := range line {
-			if toSaveTxt {
-				tempAscii += char.Simple
-				continue
+	if blinkBrightest && char.Index == brightestIndex {
+		return "\x1b[5m" + display + "\x1b[25m"
+	} else if concealDarkest && char.Index == 0 {
+		return "\x1b[8m" + display + "\x1b[28m"
+	}
+	return display
+}
+
+// Fields available to Flags.CellTemplate
+type templateCell struct {
+	Char     string
+	R, G, B  uint32
+	Col, Row int
+}
+
+// Executes Flags.CellTemplate for each character of ascii art, separating rows with Flags.RowSeparator
+func executeCellTemplate(asciiSet [][]imgManip.AsciiChar) (string, error) {
+	var buf bytes.Buffer
+
+	for y, line := range asciiSet {
+		if y > 0 {
+			buf.WriteString(rowSeparator)
+		}
+
+		for x, char := range line {
+			cell := templateCell{
+				Char: char.Simple,
+				R:    char.RgbValue[0],
+				G:    char.RgbValue[1],
+				B:    char.RgbValue[2],
+				Col:  x,
+				Row:  y,
 			}
 
-			if colored {
-				tempAscii += char.OriginalColor
-			} else if fontColor != [3]int{255, 255, 255} {
-				tempAscii += char.SetColor
-			} else {
-				tempAscii += char.Simple
+			if err := cellTemplate.Execute(&buf, cell); err != nil {
+				return "", fmt.Errorf("can't execute cell template: %v", err)
 			}
 		}
+	}
+
+	return buf.String(), nil
+}
 
-		ascii = append(ascii, tempAscii)
+// Swaps rows and columns of ascii art, so that each character's colors move along with it
+func transposeAsciiSet(asciiSet [][]imgManip.AsciiChar) [][]imgManip.AsciiChar {
+	transposed := make([][]imgManip.AsciiChar, len(asciiSet[0]))
+
+	for x := range transposed {
+		transposed[x] = make([]imgManip.AsciiChar, len(asciiSet))
+		for y := range asciiSet {
+			transposed[x][y] = asciiSet[y][x]
+		}
 	}
 
-	return ascii
+	return transposed
 }
 
 // Returns path with the file name concatenated to it
@@ -169,6 +264,150 @@ func clearScreen() {
 	}
 }
 
+// Returns true if stdout is a terminal, so that terminal control sequences don't leak into piped output
+func isOutputTerminal() bool {
+	fileInfo, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return fileInfo.Mode()&os.ModeCharDevice != 0
+}
+
+/*
+Returns true if background, or the terminal background detected from COLORFGBG if background is all -1,
+is light. COLORFGBG is set by some terminals as "foreground;background" palette indexes, where 7 and 15
+are the light backgrounds. Returns false if the background can't be detected
+*/
+func terminalBackgroundIsLight(background [3]int) bool {
+
+	if background != [3]int{-1, -1, -1} {
+		luminance := 0.299*float64(background[0]) + 0.587*float64(background[1]) + 0.114*float64(background[2])
+		return luminance > 127
+	}
+
+	colorFgBg := os.Getenv("COLORFGBG")
+	if colorFgBg == "" {
+		return false
+	}
+
+	// Some terminals add a middle field, so the background is always the last one
+	fields := strings.Split(colorFgBg, ";")
+	index, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil {
+		return false
+	}
+
+	return index == 7 || index == 15
+}
+
+// Returns true if Flags.TimeBudget is set and has run out for the current conversion
+func budgetExceeded() bool {
+	return timeBudget > 0 && time.Since(conversionStart) > timeBudget
+}
+
+// Surrounds ascii art with the terminal control sequences enabled through flags. Nothing is
+// added if stdout isn't a terminal, or if Flags.SingleLineEscaped is set, which escapes it instead
+func wrapForTerminal(ascii string) string {
+	if singleLine {
+		return escapeToSingleLine(ascii)
+	}
+
+	if !isOutputTerminal() {
+		return ascii
+	}
+
+	if cursorSaveRest {
+		ascii = saveRestoreCursor(ascii)
+	}
+
+	titleStart, titleEnd := getTerminalTitleSequences()
+
+	return titleStart + ascii + titleEnd
+}
+
+// Escapes backslashes and control characters of ascii, as described for Flags.SingleLineEscaped
+func escapeToSingleLine(ascii string) string {
+
+	var escaped strings.Builder
+
+	for _, r := range ascii {
+		switch {
+		case r == '\\':
+			escaped.WriteString(`\\`)
+		case r == '\n':
+			escaped.WriteString(`\n`)
+		case r == '\r':
+			escaped.WriteString(`\r`)
+		case r == '\t':
+			escaped.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&escaped, `\x%02x`, r)
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+
+	return escaped.String()
+}
+
+// Returns cursor to its position before printing ascii
+func saveRestoreCursor(ascii string) string {
+	return "\x1b7" + ascii + "\x1b8"
+}
+
+/*
+Returns the sequences that set the terminal title to the input name and restore the previous title through
+the terminal's title stack, if Flags.SetTerminalTitle is true. For still images, only the title is set,
+since restoring it right after printing would undo it. Empty strings are returned if stdout isn't a terminal
+*/
+func getTerminalTitleSequences() (string, string) {
+	if !setTitle || !isOutputTerminal() {
+		return "", ""
+	}
+
+	// Control characters in the name could end the sequence early
+	title := strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, inputName)
+
+	titleSequence := "\x1b]2;" + title + "\x07"
+
+	if !inputIsGif || forceStatic {
+		return titleSequence, ""
+	}
+
+	// Push current title on the stack before setting the new one, and pop it afterwards
+	return "\x1b[22;2t" + titleSequence, "\x1b[23;2t"
+}
+
+/*
+Prints titleEnd and exits if the program is interrupted, e.g. through Ctrl+C, since gifs that loop forever
+never reach the end of their animation where the title would be restored. The returned function stops
+listening for the interrupt once the animation finishes
+*/
+func restoreTitleOnInterrupt(titleEnd string) func() {
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-interrupts:
+			fmt.Print(titleEnd)
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(interrupts)
+		close(done)
+	}
+}
+
 func isInputFromPipe() bool {
 	fileInfo, _ := os.Stdin.Stat()
 	return fileInfo.Mode()&os.ModeCharDevice == 0
@@ -0,0 +1,128 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"strings"
+	"testing"
+
+	imgManip "github.com/TheZoraiz/ascii-image-converter/image_manipulation"
+)
+
+// Returns an error message if a set code of pair is found while it's already on, or a reset code
+// while it's off, or if it's still on at the end of line
+func checkSgrBalance(line, set, reset string) string {
+	on := false
+
+	for i := 0; i < len(line); i++ {
+		switch {
+		case strings.HasPrefix(line[i:], set):
+			if on {
+				return "set again before being reset"
+			}
+			on = true
+		case strings.HasPrefix(line[i:], reset):
+			if !on {
+				return "reset without being set"
+			}
+			on = false
+		}
+	}
+
+	if on {
+		return "still set at the end of the line"
+	}
+	return ""
+}
+
+func TestStyleRampLevelResets(t *testing.T) {
+	defer func() { blinkBrightest, concealDarkest, brightestIndex = false, false, 0 }()
+	if err := setFlags(DefaultFlags()); err != nil {
+		t.Fatal(err)
+	}
+	blinkBrightest, concealDarkest, brightestIndex = true, true, 2
+
+	// Darkest, middle and brightest levels of a 3 character set, with colors like --color gives them
+	line := []imgManip.AsciiChar{
+		{Simple: " ", OriginalColor: "\x1b[38;2;0;0;0m \x1b[0m", Index: 0},
+		{Simple: ".", OriginalColor: "\x1b[38;2;128;128;128m.\x1b[0m", Index: 1},
+		{Simple: "@", OriginalColor: "\x1b[38;2;255;255;255m@\x1b[0m", Index: 2},
+		{Simple: "@", OriginalColor: "\x1b[38;2;250;250;250m@\x1b[0m", Index: 2},
+		{Simple: " ", OriginalColor: "\x1b[38;2;5;5;5m \x1b[0m", Index: 0},
+	}
+
+	for _, colored := range []bool{false, true} {
+		for i, char := range line {
+			got := flattenChar(char, colored, false)
+
+			display := char.Simple
+			if colored {
+				display = char.OriginalColor
+			}
+
+			var want string
+			switch char.Index {
+			case 0:
+				want = "\x1b[8m" + display + "\x1b[28m"
+			case 2:
+				want = "\x1b[5m" + display + "\x1b[25m"
+			default:
+				want = display
+			}
+
+			if got != want {
+				t.Errorf("colored %v, char %v: got %q, want %q", colored, i, got, want)
+			}
+		}
+
+		// Adjacent styled characters each reset their own code, so none of them carry over
+		flattened := flattenAscii([][]imgManip.AsciiChar{line}, colored, false)[0]
+		if problem := checkSgrBalance(flattened, "\x1b[5m", "\x1b[25m"); problem != "" {
+			t.Errorf("colored %v: blink %v in %q", colored, problem, flattened)
+		}
+		if problem := checkSgrBalance(flattened, "\x1b[8m", "\x1b[28m"); problem != "" {
+			t.Errorf("colored %v: conceal %v in %q", colored, problem, flattened)
+		}
+	}
+
+	// Saved .txt files never get codes
+	if got := flattenAscii([][]imgManip.AsciiChar{line}, false, true)[0]; got != " .@@ " {
+		t.Errorf("saved txt: got %q, want \" .@@ \"", got)
+	}
+}
+
+func TestStyleRampLevelOnlyForTerminal(t *testing.T) {
+	defer func() { blinkBrightest, concealDarkest, brightestIndex = false, false, 0 }()
+
+	// Saving output never enables the codes, whether or not stdout is a terminal
+	flags := DefaultFlags()
+	flags.BlinkBrightest = true
+	flags.ConcealDarkest = true
+	flags.SaveTxtPath = "."
+
+	if err := setFlags(flags); err != nil {
+		t.Fatal(err)
+	}
+	if brightestIndex != 0 {
+		t.Fatalf("brightestIndex is %v while saving, want 0", brightestIndex)
+	}
+
+	char := imgManip.AsciiChar{Simple: "@", Index: 9}
+	if got := flattenChar(char, false, false); got != "@" {
+		t.Errorf("got %q while saving, want no codes", got)
+	}
+}
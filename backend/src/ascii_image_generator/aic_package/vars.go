@@ -16,6 +16,14 @@ limitations under the License.
 
 package aic_package
 
+import (
+	"image"
+	"text/template"
+	"time"
+
+	"github.com/disintegration/imaging"
+)
+
 type Flags struct {
 	// Set dimensions of ascii art. Accepts a slice of 2 integers
 	// e.g. []int{60,30}.
@@ -99,33 +107,535 @@ type Flags struct {
 	// is meant for braille art. Therefore, it will be ignored if Flags.Braille is false
 	Dither bool
 
-	// If Flags.SaveImagePath, Flags.SaveTxtPath or Flags.SaveGifPath are set, then don't
-	// print on terminal
+	// If Flags.SaveImagePath, Flags.SaveTxtPath, Flags.SaveGifPath or Flags.SaveAnsiMoviePath
+	// are set, then don't print on terminal
 	OnlySave bool
+
+	// Treat the input image as already grayscale, skipping color extraction from each pixel.
+	// This is faster for grayscale scans and always results in monochrome ascii art.
+	// Setting this along with Flags.Colored will throw an error
+	AssumeGrayscale bool
+
+	// Wrap ascii art printed on the terminal with the DEC cursor save and restore sequences,
+	// so the cursor returns to where it was before printing. Gif frames are then redrawn in
+	// place instead of clearing the screen. This will be ignored if stdout isn't a terminal
+	CursorSaveRestore bool
+
+	// Convert the decoded image into the passed color model before ascii conversion.
+	// Accepts "rgba" for alpha-premultiplied colors or "nrgba" for non-premultiplied
+	// (straight) colors. Leaving it empty keeps whichever model the decoder returned.
+	// Premultiplied colors lose precision in semi-transparent pixels, so "nrgba" is
+	// recommended for images with alpha and is used by DefaultFlags()
+	DecodeColorModel string
+
+	// Limit the number of distinct characters used in ascii art. The character set chosen
+	// through Flags.Complex or Flags.CustomMap is reduced to this many evenly spaced
+	// characters. Value provided must be 0 (use full character set) or at least 2.
+	// This will be ignored if Flags.Braille is set to true
+	MaxGlyphs int
+
+	// Resample gif frames to a constant frame rate for terminal display and saved gifs. For each
+	// tick of 1/TargetFPS seconds, the frame active at that time in the original gif is shown, so
+	// frames with irregular delays play evenly. Saved gif delays are rounded to 100ths of a second.
+	// Value provided must be from 0 to 100, since gif delays can't be shorter than 1/100th of a
+	// second. 0 keeps the original frame delays. This will be ignored if input isn't a gif
+	TargetFPS float64
+
+	// Embed png iTXt chunks in the file saved through Flags.SaveImagePath, containing the source
+	// path/url, creation time and a JSON encoding of the flags used, so it can be known later how
+	// the image was generated. This doesn't affect the image itself. Images returned by
+	// ConvertToImageBytes() and written by ConvertMulti() get them too
+	EmbedMetadata bool
+
+	// Read tiff images one row at a time and downsample them while decoding, instead of holding the
+	// whole decoded image in memory. This allows converting images too large to fit in memory.
+	// Only uncompressed, strip based tiffs with 8 bits per sample in grayscale, RGB or RGBA are
+	// supported. Other formats and tiff layouts fall back to decoding the whole image
+	StreamDecode bool
+
+	// Use a mode where each character represents multiple pixels, like Flags.Braille. Accepts
+	// "legacy2x2", where each character covers 2x2 pixels and is the block character whose filled
+	// quadrants are closest to their brightness, out of the quadrant blocks (e.g. ▚, ▙ and █), the
+	// shades ░, ▒ and ▓, and the half shaded blocks of Symbols for Legacy Computing (e.g. U+1FB8C
+	// and U+1FB91). Flags.Threshold isn't used, although Flags.Dither and Flags.AdaptiveThreshold
+	// make each quadrant fully on or off. Terminal and font must support the Unicode Block Elements
+	// and Symbols for Legacy Computing (U+1FB00 to U+1FBFF), which most fonts, including those used
+	// for saved images, lack; a warning is printed when saving with such a font. Leaving it empty
+	// uses normal ascii characters. Setting this along with Flags.Braille will throw an error
+	DotMode string
+
+	// Transpose ascii art after conversion, so that rows become columns, for vertical banners.
+	// Unlike rotating or flipping the image, this moves the converted characters themselves,
+	// so characters keep their appearance instead of being mapped from a rotated image.
+	// This applies to terminal display as well as saved .txt, .png and .gif files
+	TransposeOutput bool
+
+	// Minimum delay of each frame in the gif saved through Flags.SaveGifPath, in 100ths of a second.
+	// Frames with shorter delays are raised to it. Most browsers play frames with delays below 2 as if
+	// they were 10, so setting this to 2 keeps fast gifs playing at the same speed across viewers.
+	// Value provided must not be negative
+	MinFrameDelay int
+
+	// Use a built-in character set instead of the default one. Accepts "shade", which uses the
+	// block shading characters " ░▒▓█" ordered by how much of the character is filled, so that
+	// gradients stay smooth. This works with Flags.Colored, coloring each block with the
+	// original color. This overrides Flags.Complex, and is overridden by Flags.CustomMap
+	RampPreset string
+
+	// Go text/template executed for each character of ascii art instead of the usual output,
+	// e.g. "<span style=\"color:rgb({{.R}},{{.G}},{{.B}})\">{{.Char}}</span>". Available fields
+	// are {{.Char}}, {{.R}}, {{.G}}, {{.B}}, {{.Col}} and {{.Row}}. This only applies to the
+	// returned ascii art of images, not terminal display of gifs or saved files
+	CellTemplate string
+
+	// Placed between rows when Flags.CellTemplate is set. Defaults to a newline if left empty
+	RowSeparator string
+
+	// If a jpeg has an embedded EXIF thumbnail with at least as many pixels as the ascii art needs,
+	// convert the thumbnail instead of decoding the full image. This is much faster for small ascii
+	// art of large photos, but the thumbnail may be more compressed than the original image.
+	// Images without a thumbnail, with one too small for the ascii art, or with one whose aspect ratio
+	// differs from the image, e.g. a letterboxed thumbnail of a portrait photo, are decoded fully
+	UseEmbeddedThumbnail bool
+
+	// Path to save ascii art gif frames as a .ansi movie file, if gif is passed. Unlike
+	// Flags.SaveGifPath, frames keep their exact terminal colors. The file can be played
+	// with PlayAnsiMovie() and its format is documented in aic_package/readme.md
+	SaveAnsiMoviePath string
+
+	// Which value of each pixel decides its character. Accepts "luma" for the combined grayscale
+	// value, "r", "g" or "b" for a single color channel, "alpha" for opacity, and "max" or "min"
+	// for the brightest or darkest of the color channels. Flags.Colored still colors characters
+	// with the full original color. This will be ignored if Flags.AssumeGrayscale is true.
+	// Defaults to "luma" if left empty
+	LuminanceChannel string
+
+	// Only convert frames of gif that are shown from this time onwards, based on the delays of
+	// previous frames. Frames partially inside the time window keep only that part of their delay.
+	// The window is picked before frames are resampled through Flags.TargetFPS. Value provided
+	// must not be negative, or exceed the duration of the gif. This will be ignored if input isn't a gif
+	StartTime time.Duration
+
+	// Only convert frames of gif that are shown within this long after Flags.StartTime. Windows
+	// extending past the end of the gif are clamped to it. Value provided must not be negative.
+	// 0 converts frames until the end of the gif. This will be ignored if input isn't a gif
+	Duration time.Duration
+
+	// Placed at the start of each line of ascii art, e.g. "    " to embed it in an indented
+	// yaml or code block. This applies to the returned ascii art, terminal display of gifs and
+	// saved .txt files. It's placed before any color codes and isn't counted in ascii art width.
+	// This will be ignored if Flags.CellTemplate is set
+	Indent string
+
+	// Convert only the first frame of a gif like any other image, without displaying the animation.
+	// Flags.SaveImagePath and Flags.SaveTxtPath save that frame, while Flags.SaveGifPath,
+	// Flags.SaveAnsiMoviePath and other gif specific flags are ignored
+	ForceStatic bool
+
+	// Size each character cell of images saved through Flags.SaveImagePath by the font's advance
+	// width and line height, instead of a fixed cell twice as tall as it's wide. Characters are
+	// spaced exactly as the font intends, and the saved image's rows and columns are calculated
+	// from the ratio of the cell's height to its width, so it keeps the original image's aspect
+	// ratio. Rows and columns given through Flags.Dimensions are kept as they are. This also applies
+	// to ConvertToImageBytes() and png targets of ConvertMulti(), but is ignored for terminal
+	// display, saved .txt files and saved gifs, which keep characters twice as tall as they're wide
+	AspectFromFont bool
+
+	// Strength of darkening applied to pixels towards the edges and corners of the image, from 0 (off)
+	// to 1, where corners become fully dark. This is applied before characters are picked, so it
+	// fades characters as well as their colors with Flags.Colored. Value provided must be from 0 to 1
+	Vignette float64
+
+	// Set the terminal window title to the name of the input file while displaying ascii art.
+	// For gifs, the previous title is restored once the animation finishes, or when the program
+	// is interrupted through Ctrl+C, which is how gifs that loop forever end. Title is only set
+	// if output is a terminal, so it's never included in piped output
+	SetTerminalTitle bool
+
+	// Path to a grayscale image used as a mask, which is resized to the ascii art through the filter of
+	// Flags.Resampling. Characters are hidden (left blank) where the mask is black, shown normally where
+	// it's white, and faded towards the darkest character where it's gray. Braille and dot characters are
+	// hidden only where the mask is black over all of their dots. Colored mask images are converted to
+	// grayscale
+	MaskPath string
+
+	// Pad ascii art with blank characters on its right until its number of columns is a multiple
+	// of this value, e.g. for tiling it in blocks of 8 characters. Columns are always rounded up,
+	// so no part of the image is cropped. Value provided must not be negative. 0 and 1 keep the
+	// number of columns as it is. This applies after Flags.TransposeOutput
+	WidthMultiple int
+
+	// Like Flags.WidthMultiple, but pads ascii art at the bottom to round up its number of rows
+	HeightMultiple int
+
+	// RGB color that semi-transparent pixels are composited over before their brightness and color
+	// are used, e.g. [3]int{255, 255, 255} for images meant to be seen on a white background.
+	// Defaults to black, which fades transparent areas towards the darkest character
+	BlendAlphaAgainst [3]int
+
+	// Placed at the start of each line of ascii art after Flags.Indent, e.g. "| " or "# ".
+	// Like Flags.Indent, it's placed outside of color codes, isn't counted in ascii art width,
+	// and will be ignored if Flags.CellTemplate is set
+	LinePrefix string
+
+	// Placed at the end of each line of ascii art after any color codes, e.g. " |".
+	// This works the same way as Flags.LinePrefix otherwise
+	LineSuffix string
+
+	// Detect edges in the image along with the usual conversion, and replace characters on strong
+	// edges with "-", "|", "/" or "\" following the edge's direction, keeping their colors.
+	// Characters elsewhere are picked from the character set as usual. This will be ignored if
+	// Flags.Braille or Flags.DotMode is set
+	DetailOverlay bool
+
+	// Edge strength above which Flags.DetailOverlay replaces characters, where a sharp edge
+	// between black and white has a strength of 255. Lower values outline softer edges too.
+	// Value provided must not be negative
+	EdgeThreshold int
+
+	// Wrap each line of ascii art after this many characters, continuing it on the following lines
+	// instead of resizing the ascii art, e.g. for printing wide ascii art on a narrow terminal.
+	// This changes the layout of the ascii art, since each row takes multiple lines. It applies to
+	// the returned ascii art, terminal display of gifs and saved .txt files. Value provided must
+	// not be negative. 0 doesn't wrap lines
+	WrapAt int
+
+	// Turn a still image into an animation of a viewport moving across it, e.g. for panoramas.
+	// Frames are displayed like a gif, and saved as a gif through Flags.SaveGifPath.
+	// This will be ignored if input is a gif, unless Flags.ForceStatic is true
+	ScrollAnimation ScrollAnimation
+
+	// Compare each pixel against a threshold computed from its surrounding pixels (Sauvola
+	// binarization), instead of the global Flags.Threshold. This keeps fine strokes, like text in
+	// screenshots and scanned documents, legible in images with uneven brightness.
+	// This will be ignored if neither Flags.Braille nor Flags.DotMode is set
+	AdaptiveThreshold bool
+
+	// Width and height of the window around each pixel used for Flags.AdaptiveThreshold, in pixels
+	// of the downscaled image (each braille character covers 2x4 pixels). Value provided must be
+	// at least 3. This will be ignored if Flags.AdaptiveThreshold is false
+	AdaptiveWindow int
+
+	// Convert the decodable part of images that fail to decode because they're truncated, e.g. from
+	// an interrupted download, instead of returning an error. A warning is printed to stderr with the
+	// number of rows recovered, and the missing rows are filled with Flags.BlendAlphaAgainst. Only
+	// baseline jpegs and non-interlaced pngs are supported; progressive jpegs, interlaced pngs, gifs
+	// and other formats still return an error
+	AllowPartialDecode bool
+
+	// Toggle Flags.Negative if the terminal has a light background, so that dark parts of images
+	// stay dark in the terminal regardless of its theme. The background is taken from
+	// Flags.TerminalBackground, or detected through the COLORFGBG environment variable.
+	// This will be ignored if stdout isn't a terminal, the background can't be detected, or any
+	// of the saving flags are set, since saved files don't depend on the terminal
+	AutoInvertForBackground bool
+
+	// RGB color of the terminal background for Flags.AutoInvertForBackground. Values must be from
+	// 0 to 255, or all -1 to detect it instead, which DefaultFlags() uses
+	TerminalBackground [3]int
+
+	// Resolution in dots per inch written to the pHYs chunk of the file saved through
+	// Flags.SaveImagePath, so it's printed at the right physical size. The chunk stores pixels per
+	// meter, which is OutputDPI / 0.0254. Images returned by ConvertToImageBytes() and written by
+	// ConvertMulti() get it too. Value provided must not be negative. 0 leaves it out
+	OutputDPI int
+
+	// Scale saved images so that the ascii art fills a sheet of paper printed at Flags.OutputDPI.
+	// Accepts "a3", "a4", "a5", "letter" or "legal". A sheet of W x H millimeters holds
+	// W / 25.4 * OutputDPI by H / 25.4 * OutputDPI pixels. The font size is scaled by the largest
+	// factor that fits the image in the sheet, in portrait or landscape orientation, whichever
+	// is larger. This will be ignored if Flags.OutputDPI is 0
+	PaperSize string
+
+	// Limit how long conversion takes, returning a lower quality result instead of running over.
+	// For gifs, frames stop being converted once the budget runs out, and only the frames converted
+	// so far are displayed or saved, so the animation ends early. For still images, if reading and
+	// decoding has already taken over half of the budget, the decoded image is first shrunk to at
+	// most 1024 pixels on its longest side with nearest neighbor sampling, which is faster than the
+	// usual resizing but loses detail. The budget can still be exceeded, since converting a frame
+	// once started isn't interrupted, and saving files afterwards isn't limited.
+	// Value provided must not be negative. 0 disables the budget
+	TimeBudget time.Duration
+
+	// Show the image as a grid of colored pixels, using the full block character █ for every cell,
+	// colored with the original colors like Flags.Colored, so each character is one pixel of a low
+	// resolution color display. Colors use 24-bit codes if the terminal supports them, and 8-bit
+	// otherwise. Without Flags.Dimensions, Flags.Width or Flags.Height, the ascii art is sized to fit
+	// the terminal as usual. This overrides Flags.Complex, Flags.CustomMap, Flags.Grayscale and
+	// Flags.FontColor. Setting this along with Flags.Braille, Flags.DotMode or Flags.AssumeGrayscale
+	// will throw an error
+	Mosaic bool
+
+	// Write premultiplied colors to saved png images, i.e. each pixel's color multiplied by its alpha,
+	// which is only useful for tools that expect png data to be premultiplied. By default, pngs hold
+	// straight (non-premultiplied) colors as the png format specifies, so partially transparent pixels,
+	// such as anti-aliased glyph edges over a translucent Flags.SaveBackgroundColor, keep their color.
+	// Premultiplied pixels look darker at those edges when read as straight colors
+	PremultipliedOutput bool
+
+	// Terminal width and height in characters, used instead of detecting them from stdout, e.g. for
+	// wrappers running this behind a pipe that know the size of the real terminal. The terminal size
+	// decides the ascii art dimensions when Flags.Full is set, or when none of Flags.Dimensions,
+	// Flags.Width and Flags.Height are, which take precedence otherwise. Each of them can be set
+	// alone, leaving the other one detected. Values provided must not be negative. 0 detects them
+	TermWidth  int
+	TermHeight int
+
+	// Filter used for resizing the image to the ascii art dimensions, and for scaling images that differ
+	// in size to the largest of them in ConvertAveraged(). Gif frames of differing sizes are placed on
+	// the gif's logical screen first, so each of them is resized like a whole image. Accepts "lanczos",
+	// "catmullrom", "linear", "box" or "nearest". Sharp filters like lanczos keep fine detail, while "nearest" keeps hard pixel edges,
+	// e.g. for pixel art. Defaults to "lanczos" if left empty
+	Resampling string
+
+	// Return ascii art of still images as a single line, for embedding it in log messages. Newlines,
+	// backslashes, color escape codes and other control characters are replaced with the escapes
+	// \n, \\ and \x1b, along with \r, \t and \xHH for the rest, so the original ascii art, colors
+	// included, can be restored by un-escaping them. Terminal title and cursor sequences aren't
+	// added. This doesn't apply to terminal display of gifs or saved files
+	SingleLineEscaped bool
+
+	// Make characters at the brightest level of the character set blink, and hide characters at its
+	// darkest level while keeping their space, through terminal escape codes. For braille and
+	// Flags.DotMode, these levels are the characters with all dots and with none. Character sets
+	// with a single character are left as they are. Like Flags.CursorSaveRestore, codes are only
+	// added when stdout is a terminal and no saving flags are passed, so they never end up in
+	// saved files or in ascii art returned to be written elsewhere
+	BlinkBrightest bool
+	ConcealDarkest bool
+
+	// Size the file saved through Flags.SaveImagePath to the width and height of the source image,
+	// so that it can replace the source in a layout. This is approximate, since characters keep the
+	// width and height of the ascii art dimensions: cells are stretched to fill the image, and the
+	// font is scaled to fit the cells in whichever direction is tighter, which leaves some extra space
+	// around characters in the other one. Sources too small for a pixel per character, after 5 pixels
+	// of padding on each side, come out larger. Can't be used along with Flags.PaperSize
+	MatchSourceResolution bool
+
+	// Snap each character to fully on or off, as the full block character █ or a space, for images
+	// that are already black and white, such as QR codes and barcodes. Each character is on if most
+	// of the source pixels it covers are bright, which box resampling decides by averaging them. For
+	// modules to stay square and crisp, Flags.Dimensions should be a multiple of the number of modules,
+	// with twice as many columns as rows per module. This overrides Flags.Complex, Flags.CustomMap,
+	// Flags.Colored, Flags.Grayscale, Flags.Resampling and Flags.DetailOverlay. Setting this along with
+	// Flags.Braille, Flags.DotMode or Flags.Mosaic will throw an error
+	BinaryPreserve bool
+
+	// Draw a blurred copy of the characters beneath them in files saved through Flags.SaveImagePath
+	// and Flags.SaveGifPath, offset by Flags.ShadowOffset. The images are enlarged on the sides the
+	// shadow extends to, so that it isn't clipped. This will be ignored if neither is set
+	DropShadow bool
+
+	// RGBA color of the shadow of Flags.DropShadow. Like Flags.SaveBackgroundColor, RGB values must
+	// be from 0 to 255, and the opacity from 0 to 100. DefaultFlags() uses {0, 0, 0, 60}
+	ShadowColor [4]int
+
+	// Horizontal and vertical distance in pixels of the shadow of Flags.DropShadow from the characters.
+	// Negative values move it left or up. DefaultFlags() uses {4, 4}
+	ShadowOffset [2]int
+
+	// Compare each color channel against its own value of Flags.ChannelThresholds instead of comparing
+	// brightness against Flags.Threshold, which gives a separate dot pattern for each channel. They're
+	// overlaid into each character, colored by how many of its dots come from each pattern, like color
+	// separations of screen printing. This implies Flags.Colored. Flags.Dither and
+	// Flags.AdaptiveThreshold are ignored. Requires Flags.Braille or Flags.DotMode, and can't be used
+	// along with Flags.AssumeGrayscale
+	ChannelSeparated bool
+
+	// Red, green and blue thresholds for Flags.ChannelSeparated, each between 0 and 255.
+	// DefaultFlags() uses {128, 128, 128}
+	ChannelThresholds [3]int
+
+	// Color characters with the 16 base colors of the terminal instead of 24-bit or 8-bit colors,
+	// picking whichever color of Flags.Theme16 is nearest to each pixel. This also works on terminals
+	// that only support 16 colors. Saved files keep the original colors
+	Color16 bool
+
+	// RGB values of the 16 base colors as the terminal theme actually shows them, from black (0),
+	// red, green, yellow, blue, magenta and cyan to white (7), followed by their bright versions (8-15),
+	// for Flags.Color16. These are listed in the color scheme settings of most terminals, e.g. the
+	// ANSI colors of a profile, or can be queried from the terminal with the escape sequence
+	// "\x1b]4;<index>;?\x07", which it answers with "rgb:RRRR/GGGG/BBBB", where the first two hex
+	// digits of each part are the value from 0 to 255. Values must be from 0 to 255. DefaultFlags()
+	// uses the xterm colors, which are used as well if this is left as all zeros
+	Theme16 [16][3]int
+
+	// Color braille characters with both a foreground and a background color. Highlighted dots get the
+	// average color of the pixels they came from, and the rest of each character gets the average color
+	// of its other pixels, which gives braille art half block style coloring at its full resolution. Colors
+	// are 24-bit, 8-bit or 16-color according to the terminal and Flags.Color16. This implies
+	// Flags.Colored unless Flags.Grayscale is set. Flags.FontColor is ignored, and saved images and gifs
+	// only show the foreground colors. Requires Flags.Braille, and can't be used along with
+	// Flags.CharBackgroundColor or Flags.ChannelSeparated
+	BrailleColorFull bool
+
+	// Write saved files to a temporary file in the same directory that's renamed into place once it's
+	// complete. If writing fails midway, e.g. because the disk is full, the temporary file is removed and
+	// no partially written file is left for other programs to read. This applies to every saved file,
+	// including Flags.SaveTxtPath, Flags.SaveImagePath and Flags.SaveGifPath. DefaultFlags() sets it to
+	// true, but it's false in a Flags literal that leaves it out, which writes saved files directly
+	AtomicWrites bool
+
+	// Which palette color is used for 8-bit and Flags.Color16 terminal colors when several of them are
+	// equally close to a pixel. Accepts "lowest" for the lowest palette index or "highest" for the highest
+	// one. Either way, identical pixels always get the same color, so static parts of gifs don't flicker.
+	// Defaults to "lowest" if left empty
+	ColorTieBreak string
+
+	// Dither with an ordered Bayer matrix instead of Floyd-Steinberg when Flags.Dither is set. Error
+	// diffusion carries each pixel's rounding error to the pixels after it, so any change in a gif frame
+	// shifts the dots of static regions below and to the right of it, which shimmer from frame to frame.
+	// Ordered dithering decides each dot from its own pixel and position alone, so static regions keep
+	// identical dots in every frame. Dots form a regular cross-hatch pattern instead of diffused noise.
+	// This will be ignored if Flags.Dither is not set
+	StableNoise bool
+}
+
+type ScrollAnimation struct {
+	// Direction the viewport moves in across the image. Accepts "right", "left", "down" or "up"
+	Direction string
+
+	// Number of frames in the animation, from the viewport at one edge of the image to the
+	// opposite edge. 0 disables the animation
+	Frames int
+
+	// Dimensions of the viewport in pixels of the image. 0 uses the full width or height of
+	// the image. Viewport must fit inside the image
+	ViewportWidth  int
+	ViewportHeight int
+
+	// Delay of each frame in 100ths of a second
+	FrameDelay int
+}
+
+// Accepted values of Flags.LuminanceChannel
+var luminanceChannels = map[string]bool{"luma": true, "r": true, "g": true, "b": true, "alpha": true, "max": true, "min": true}
+
+// Accepted values of Flags.Resampling
+var resampleFilters = map[string]imaging.ResampleFilter{
+	"lanczos":    imaging.Lanczos,
+	"catmullrom": imaging.CatmullRom,
+	"linear":     imaging.Linear,
+	"box":        imaging.Box,
+	"nearest":    imaging.NearestNeighbor,
+}
+
+// Default xterm values of the 16 base terminal colors, for Flags.Theme16
+var xtermTheme16 = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// Character sets for Flags.RampPreset, ordered from darkest to lightest
+var rampPresets = map[string]string{
+	// Filled area of each character is roughly 0%, 25%, 50%, 75% and 100%
+	"shade": " ░▒▓█",
 }
 
 var (
-	dimensions    []int
-	width         int
-	height        int
-	complex       bool
-	saveTxtPath   string
-	saveImagePath string
-	saveGifPath   string
-	grayscale     bool
-	negative      bool
-	colored       bool
-	colorBg       bool
-	customMap     string
-	flipX         bool
-	flipY         bool
-	full          bool
-	fontPath      string
-	fontColor     [3]int
-	saveBgColor   [4]int
-	braille       bool
-	threshold     int
-	dither        bool
-	onlySave      bool
-	inputIsGif    bool
+	dimensions      []int
+	width           int
+	height          int
+	complex         bool
+	saveTxtPath     string
+	saveImagePath   string
+	saveGifPath     string
+	grayscale       bool
+	negative        bool
+	colored         bool
+	colorBg         bool
+	customMap       string
+	flipX           bool
+	flipY           bool
+	full            bool
+	fontPath        string
+	fontColor       [3]int
+	saveBgColor     [4]int
+	braille         bool
+	threshold       int
+	dither          bool
+	onlySave        bool
+	assumeGrayscale bool
+	cursorSaveRest  bool
+	decodeModel     string
+	maxGlyphs       int
+	targetFPS       float64
+	embedMetadata   bool
+	streamDecode    bool
+	dotMode         string
+	transposeOutput bool
+	minFrameDelay   int
+	rampPreset      string
+	cellTemplate    *template.Template
+	rowSeparator    string
+	useThumbnail    bool
+	saveMoviePath   string
+	lumaChannel     string
+	startTime       time.Duration
+	clipDuration    time.Duration
+	indent          string
+	forceStatic     bool
+	aspectFromFont  bool
+	vignette        float64
+	setTitle        bool
+	maskImage       image.Image
+	widthMultiple   int
+	heightMultiple  int
+	blendColor      [3]int
+	linePrefix      string
+	lineSuffix      string
+	detailOverlay   bool
+	edgeThreshold   int
+	wrapAt          int
+	scroll          ScrollAnimation
+	adaptive        bool
+	adaptiveWindow  int
+	allowPartial    bool
+	outputDPI       int
+	paperSize       string
+	timeBudget      time.Duration
+	premultiplied   bool
+	termWidth       int
+	termHeight      int
+	resampleFilter  imaging.ResampleFilter
+	singleLine      bool
+	blinkBrightest  bool
+	concealDarkest  bool
+	matchSource     bool
+	dropShadow      bool
+	shadowRGBA      [4]int
+	shadowOffset    [2]int
+	separated       bool
+	channelLimits   [3]int
+	brailleFull     bool
+	atomicWrites    bool
+	stableNoise     bool
+
+	// Index of the brightest level of the character set, for Flags.BlinkBrightest and Flags.ConcealDarkest
+	brightestIndex int
+
+	// Character cell size of saved images measured from the font by loadFont(), for Flags.AspectFromFont
+	fontCellWidth  float64
+	fontCellHeight float64
+
+	// Height to width ratio of characters that the ascii art is sized for, set only while converting
+	// images drawn by convertImageToSavedAsciiSet(). 0 keeps characters twice as tall as they're wide
+	cellAspect float64
+
+	// Time at which the current conversion started, for Flags.TimeBudget
+	conversionStart time.Time
+	usedFlags       Flags
+	inputIsGif      bool
+	inputName       string
+
+	// Dimensions of the last ascii art converted by Convert(), set outside of the goroutines that convert gif frames
+	convertedCols int
+	convertedRows int
+
+	// Dimensions of the image last decoded through decodeImage(), as stored in the input before any thumbnail,
+	// downsampling, shrinking for Flags.TimeBudget or resizing replaced it
+	sourceWidth  int
+	sourceHeight int
 )
@@ -19,6 +19,8 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/TheZoraiz/ascii-image-converter/aic_package"
 
@@ -53,6 +55,71 @@ var (
 	threshold     int
 	dither        bool
 	onlySave      bool
+	assumeGray    bool
+	saveCursor    bool
+	decodeModel   string
+	maxGlyphs     int
+	targetFPS     float64
+	embedMetadata bool
+	streamDecode  bool
+	dotMode       string
+	transpose     bool
+	minFrameDelay int
+	rampPreset    string
+	cellTemplate  string
+	rowSeparator  string
+	useThumbnail  bool
+	saveMoviePath string
+	lumaChannel   string
+	startTime     time.Duration
+	clipDuration  time.Duration
+	indent        string
+	forceStatic   bool
+	fontAspect    bool
+	vignette      float64
+	setTitle      bool
+	maskPath      string
+	widthMult     int
+	heightMult    int
+	blendBgColor  []int
+	linePrefix    string
+	lineSuffix    string
+	detailOverlay bool
+	edgeThreshold int
+	wrapAt        int
+	scrollDir     string
+	scrollFrames  int
+	scrollView    []int
+	adaptive      bool
+	adaptiveWin   int
+	allowPartial  bool
+	autoInvert    bool
+	termBgColor   []int
+	outputDPI     int
+	paperSize     string
+	timeBudget    time.Duration
+	mosaic        bool
+	premultiplied bool
+	preset        string
+	termSize      []int
+	resampling    string
+	singleLine    bool
+	blink         bool
+	matchSource   bool
+	binary        bool
+	dropShadow    bool
+	shadowColor   []int
+	shadowOffset  []int
+	separated     bool
+	channelLimits []int
+	color16       bool
+	theme16       []string
+	theme16Colors [16][3]int
+	brailleFull   bool
+	noAtomic      bool
+	tieBreak      string
+	stableNoise   bool
+	conceal       bool
 
 	// Root commands
 	rootCmd = &cobra.Command{
@@ -69,28 +136,103 @@ var (
 			}
 
 			flags := aic_package.Flags{
-				Complex:             complex,
-				Dimensions:          dimensions,
-				Width:               width,
-				Height:              height,
-				SaveTxtPath:         saveTxtPath,
-				SaveImagePath:       saveImagePath,
-				SaveGifPath:         saveGifPath,
-				Negative:            negative,
-				Colored:             colored,
-				CharBackgroundColor: colorBg,
-				Grayscale:           grayscale,
-				CustomMap:           customMap,
-				FlipX:               flipX,
-				FlipY:               flipY,
-				Full:                full,
-				FontFilePath:        fontFile,
-				FontColor:           [3]int{fontColor[0], fontColor[1], fontColor[2]},
-				SaveBackgroundColor: [4]int{saveBgColor[0], saveBgColor[1], saveBgColor[2], saveBgColor[3]},
-				Braille:             braille,
-				Threshold:           threshold,
-				Dither:              dither,
-				OnlySave:            onlySave,
+				Complex:                 complex,
+				Dimensions:              dimensions,
+				Width:                   width,
+				Height:                  height,
+				SaveTxtPath:             saveTxtPath,
+				SaveImagePath:           saveImagePath,
+				SaveGifPath:             saveGifPath,
+				Negative:                negative,
+				Colored:                 colored,
+				CharBackgroundColor:     colorBg,
+				Grayscale:               grayscale,
+				CustomMap:               customMap,
+				FlipX:                   flipX,
+				FlipY:                   flipY,
+				Full:                    full,
+				FontFilePath:            fontFile,
+				FontColor:               [3]int{fontColor[0], fontColor[1], fontColor[2]},
+				SaveBackgroundColor:     [4]int{saveBgColor[0], saveBgColor[1], saveBgColor[2], saveBgColor[3]},
+				Braille:                 braille,
+				Threshold:               threshold,
+				Dither:                  dither,
+				OnlySave:                onlySave,
+				AssumeGrayscale:         assumeGray,
+				CursorSaveRestore:       saveCursor,
+				DecodeColorModel:        decodeModel,
+				MaxGlyphs:               maxGlyphs,
+				TargetFPS:               targetFPS,
+				EmbedMetadata:           embedMetadata,
+				StreamDecode:            streamDecode,
+				DotMode:                 dotMode,
+				TransposeOutput:         transpose,
+				MinFrameDelay:           minFrameDelay,
+				RampPreset:              rampPreset,
+				CellTemplate:            cellTemplate,
+				RowSeparator:            rowSeparator,
+				UseEmbeddedThumbnail:    useThumbnail,
+				SaveAnsiMoviePath:       saveMoviePath,
+				LuminanceChannel:        lumaChannel,
+				StartTime:               startTime,
+				Duration:                clipDuration,
+				Indent:                  indent,
+				ForceStatic:             forceStatic,
+				AspectFromFont:          fontAspect,
+				Vignette:                vignette,
+				SetTerminalTitle:        setTitle,
+				MaskPath:                maskPath,
+				WidthMultiple:           widthMult,
+				HeightMultiple:          heightMult,
+				BlendAlphaAgainst:       [3]int{blendBgColor[0], blendBgColor[1], blendBgColor[2]},
+				LinePrefix:              linePrefix,
+				LineSuffix:              lineSuffix,
+				DetailOverlay:           detailOverlay,
+				EdgeThreshold:           edgeThreshold,
+				WrapAt:                  wrapAt,
+				AdaptiveThreshold:       adaptive,
+				AdaptiveWindow:          adaptiveWin,
+				AllowPartialDecode:      allowPartial,
+				AutoInvertForBackground: autoInvert,
+				TerminalBackground:      [3]int{termBgColor[0], termBgColor[1], termBgColor[2]},
+				OutputDPI:               outputDPI,
+				PaperSize:               paperSize,
+				TimeBudget:              timeBudget,
+				Mosaic:                  mosaic,
+				PremultipliedOutput:     premultiplied,
+				TermWidth:               termSize[0],
+				TermHeight:              termSize[1],
+				Resampling:              resampling,
+				SingleLineEscaped:       singleLine,
+				BlinkBrightest:          blink,
+				ConcealDarkest:          conceal,
+				MatchSourceResolution:   matchSource,
+				BinaryPreserve:          binary,
+				DropShadow:              dropShadow,
+				ShadowColor:             [4]int{shadowColor[0], shadowColor[1], shadowColor[2], shadowColor[3]},
+				ShadowOffset:            [2]int{shadowOffset[0], shadowOffset[1]},
+				ChannelSeparated:        separated,
+				ChannelThresholds:       [3]int{channelLimits[0], channelLimits[1], channelLimits[2]},
+				Color16:                 color16,
+				Theme16:                 theme16Colors,
+				BrailleColorFull:        brailleFull,
+				AtomicWrites:            !noAtomic,
+				ColorTieBreak:           tieBreak,
+				StableNoise:             stableNoise,
+			}
+
+			if scrollDir != "" {
+				flags.ScrollAnimation = aic_package.ScrollAnimation{
+					Direction:      scrollDir,
+					Frames:         scrollFrames,
+					ViewportWidth:  scrollView[0],
+					ViewportHeight: scrollView[1],
+					FrameDelay:     10,
+				}
+			}
+
+			if preset != "" {
+				flags = applyPreset(cmd, flags)
 			}
 
 			if args[0] == "-" {
@@ -145,26 +287,90 @@ func init() {
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.ascii-image-converter.yaml)")
 	rootCmd.PersistentFlags().BoolVarP(&colored, "color", "C", false, "Display ascii art with original colors\nIf 24-bit colors aren't supported, uses 8-bit\n(Inverts with --negative flag)\n(Overrides --grayscale and --font-color flags)\n")
 	rootCmd.PersistentFlags().BoolVar(&colorBg, "color-bg", false, "If some color flag is passed, use that color\non character background instead of foreground\n(Inverts with --negative flag)\n(Only applicable for terminal display)\n")
+	rootCmd.PersistentFlags().BoolVar(&binary, "binary", false, "Snap characters to full blocks or spaces\nfor black and white images like QR codes\n(Overrides --complex, --map and color flags)\n")
+	rootCmd.PersistentFlags().BoolVar(&mosaic, "mosaic", false, "Display image as colored full block\ncharacters, one for each pixel\n(Overrides --complex, --map and color flags)\n")
+	rootCmd.PersistentFlags().StringVar(&preset, "flag-preset", "", "Apply a named preset combination of flags,\none of "+strings.Join(aic_package.Presets(), ", ")+"\n(Flags passed along with it are kept)\n(Not to be confused with --ramp, which\nonly picks a character set)\ne.g. --flag-preset crt\n")
 	rootCmd.PersistentFlags().IntSliceVarP(&dimensions, "dimensions", "d", nil, "Set width and height for ascii art in CHARACTER length\ne.g. -d 60,30 (defaults to terminal height)\n(Overrides --width and --height flags)\n")
 	rootCmd.PersistentFlags().IntVarP(&width, "width", "W", 0, "Set width for ascii art in CHARACTER length\nHeight is kept to aspect ratio\ne.g. -W 60\n")
 	rootCmd.PersistentFlags().IntVarP(&height, "height", "H", 0, "Set height for ascii art in CHARACTER length\nWidth is kept to aspect ratio\ne.g. -H 60\n")
 	rootCmd.PersistentFlags().StringVarP(&customMap, "map", "m", "", "Give custom ascii characters to map against\nOrdered from darkest to lightest\ne.g. -m \" .-+#@\" (Quotation marks excluded from map)\n(Overrides --complex flag)\n")
+	rootCmd.PersistentFlags().StringVar(&rampPreset, "ramp", "", "Use a built-in set of characters\nPass shade for \" ░▒▓█\" shading blocks\ne.g. --ramp shade\n(Overrides --complex flag)\n")
+	rootCmd.PersistentFlags().IntVar(&maxGlyphs, "max-glyphs", 0, "Limit number of distinct ascii characters used\nby picking evenly spaced ones from the map\ne.g. --max-glyphs 4\n")
 	rootCmd.PersistentFlags().BoolVarP(&braille, "braille", "b", false, "Use braille characters instead of ascii\nTerminal must support braille patterns properly\n(Overrides --complex and --map flags)\n")
+	rootCmd.PersistentFlags().StringVar(&dotMode, "dot-mode", "", "Use block characters that each represent\nmultiple pixels, like braille\nPass legacy2x2 for 2x2 quadrant and legacy\ncomputing shade blocks (U+1FB00 font needed)\ne.g. --dot-mode legacy2x2\n(Can't be used with --braille flag)\n")
+	rootCmd.PersistentFlags().BoolVar(&noAtomic, "no-atomic", false, "Write saved files directly instead of through\na temporary file renamed into place, which\nmay leave partial files if writing fails\n")
+	rootCmd.PersistentFlags().BoolVar(&brailleFull, "braille-full", false, "Color braille dots and the space around\nthem separately, for --braille\n(Only applicable for terminal display)\n")
+	rootCmd.PersistentFlags().BoolVar(&color16, "color16", false, "Color characters with the terminal's 16\nbase colors, picking the nearest of them\n")
+	rootCmd.PersistentFlags().StringSliceVar(&theme16, "palette16", nil, "Actual RGB hex values of the 16 base colors\nin your terminal theme for --color16, from\nblack to bright white, e.g. --palette16\n282a36,ff5555,...,ffffff\n(Defaults to xterm colors)\n")
+	rootCmd.PersistentFlags().BoolVar(&separated, "separate", false, "Give each RGB channel its own dot pattern\nfor --braille or --dot-mode, overlaid in\ncolor like screen print separations\n")
+	rootCmd.PersistentFlags().IntSliceVar(&channelLimits, "channel-thresholds", nil, "RGB thresholds for --separate\ne.g. --channel-thresholds 100,128,160\n(Defaults to 128,128,128)\n")
 	rootCmd.PersistentFlags().IntVar(&threshold, "threshold", 0, "Threshold for braille art\nValue between 0-255 is accepted\ne.g. --threshold 170\n(Defaults to 128)\n")
 	rootCmd.PersistentFlags().BoolVar(&dither, "dither", false, "Apply dithering on image for braille\nart conversion\n(Only applicable with --braille flag)\n(Negates --threshold flag)\n")
+	rootCmd.PersistentFlags().BoolVar(&stableNoise, "stable-noise", false, "Dither with a fixed pattern so static parts\nof gifs keep the same dots in every frame\n(Only applicable with --dither flag)\n")
+	rootCmd.PersistentFlags().BoolVar(&adaptive, "adaptive", false, "Use a local threshold for each pixel\ninstead of --threshold, to keep text legible\n(Only applicable with --braille and --dot-mode flags)\n")
+	rootCmd.PersistentFlags().IntVar(&adaptiveWin, "adaptive-window", 15, "Window size in pixels for --adaptive flag\n")
 	rootCmd.PersistentFlags().BoolVarP(&grayscale, "grayscale", "g", false, "Display grayscale ascii art\n(Inverts with --negative flag)\n(Overrides --font-color flag)\n")
 	rootCmd.PersistentFlags().BoolVarP(&complex, "complex", "c", false, "Display ascii characters in a larger range\nMay result in higher quality\n")
 	rootCmd.PersistentFlags().BoolVarP(&full, "full", "f", false, "Use largest dimensions for ascii art\nthat fill the terminal width\n(Overrides --dimensions, --width and --height flags)\n")
+	rootCmd.PersistentFlags().IntSliceVar(&termSize, "term-size", nil, "Terminal width and height to size ascii art\nby, instead of detecting them, e.g. when\noutput is piped. Pass 0 to detect either\ne.g. --term-size 120,40\n")
 	rootCmd.PersistentFlags().BoolVarP(&negative, "negative", "n", false, "Display ascii art in negative colors\n")
+	rootCmd.PersistentFlags().BoolVar(&autoInvert, "auto-invert", false, "Toggle --negative flag if the terminal\nbackground is light\n(Ignored with saving flags)\n")
+	rootCmd.PersistentFlags().IntSliceVar(&termBgColor, "term-bg", nil, "Terminal background color for --auto-invert\nflag. Pass an RGB value\ne.g. --term-bg 255,255,255\n(Detected from COLORFGBG by default)\n")
 	rootCmd.PersistentFlags().BoolVarP(&flipX, "flipX", "x", false, "Flip ascii art horizontally\n")
 	rootCmd.PersistentFlags().BoolVarP(&flipY, "flipY", "y", false, "Flip ascii art vertically\n")
+	rootCmd.PersistentFlags().BoolVar(&transpose, "transpose", false, "Transpose ascii art after conversion\nso rows become columns\n")
 	rootCmd.PersistentFlags().StringVarP(&saveImagePath, "save-img", "s", "", "Save ascii art as a .png file\nFormat: <image-name>-ascii-art.png\nImage will be saved in passed path\n(pass . for current directory)\n")
+	rootCmd.PersistentFlags().StringVar(&tieBreak, "tie-break", "lowest", "Palette index picked among equally close\ncolors for 8-bit and --color16 colors\nOne of lowest or highest\n")
+	rootCmd.PersistentFlags().StringVar(&resampling, "resample", "lanczos", "Filter for resizing the image to ascii art\nOne of lanczos, catmullrom, linear, box\nor nearest, e.g. --resample nearest\n")
+	rootCmd.PersistentFlags().StringVar(&lumaChannel, "channel", "luma", "Pixel value that decides each character\nOne of luma, r, g, b, alpha, max or min\ne.g. --channel r\n")
+	rootCmd.PersistentFlags().DurationVar(&startTime, "start", 0, "Only convert gif frames shown from this time\ne.g. --start 1.5s\n(Only applicable for gifs)\n")
+	rootCmd.PersistentFlags().DurationVar(&clipDuration, "duration", 0, "Only convert gif frames shown within this\nlong after --start time\ne.g. --duration 3s\n(Only applicable for gifs)\n")
+	rootCmd.PersistentFlags().DurationVar(&timeBudget, "time-budget", 0, "Return lower quality ascii art instead of\ntaking longer than this to convert, e.g.\n--time-budget 500ms. Gifs end early and\nlarge images are shrunk quickly\n")
+	rootCmd.PersistentFlags().StringVar(&indent, "indent", "", "Place this string at the start of each line\nof ascii art, along with --save-txt file\ne.g. --indent \"    \"\n")
+	rootCmd.PersistentFlags().StringVar(&linePrefix, "line-prefix", "", "Place this string at the start of each line\nof ascii art, after --indent\ne.g. --line-prefix \"| \"\n")
+	rootCmd.PersistentFlags().StringVar(&lineSuffix, "line-suffix", "", "Place this string at the end of each line\nof ascii art\ne.g. --line-suffix \" |\"\n")
+	rootCmd.PersistentFlags().IntVar(&wrapAt, "wrap", 0, "Continue lines of ascii art on the next\nlines after this many characters\ne.g. --wrap 80\n")
+	rootCmd.PersistentFlags().BoolVar(&forceStatic, "static", false, "Convert only the first frame of gifs\nlike a still image, without animation\n")
+	rootCmd.PersistentFlags().BoolVar(&fontAspect, "font-aspect", false, "Size characters of --save-img flag by\nthe font's advance width and line height,\nand fit its rows and columns to them\n")
+	rootCmd.PersistentFlags().Float64Var(&vignette, "vignette", 0, "Darken ascii art towards its edges\nPass strength from 0 to 1\ne.g. --vignette 0.6\n")
+	rootCmd.PersistentFlags().BoolVar(&detailOverlay, "edges", false, "Replace characters on strong edges with\nedge characters following their direction\n(Ignored with --braille and --dot-mode)\n")
+	rootCmd.PersistentFlags().IntVar(&edgeThreshold, "edge-threshold", 96, "Edge strength from which --edges flag\nreplaces characters, where 255 is an edge\nbetween black and white\ne.g. --edge-threshold 64\n")
+	rootCmd.PersistentFlags().BoolVar(&setTitle, "title", false, "Set terminal window title to the name\nof input while displaying ascii art\n(Only applicable for terminal display)\n")
+	rootCmd.PersistentFlags().StringVar(&maskPath, "mask", "", "Hide ascii art where this grayscale\nimage is black and fade it where gray\ne.g. --mask ./mask.png\n")
+	rootCmd.PersistentFlags().IntVar(&widthMult, "width-multiple", 1, "Pad ascii art with blank characters until\nits width is a multiple of passed value\ne.g. --width-multiple 8\n")
+	rootCmd.PersistentFlags().IntVar(&heightMult, "height-multiple", 1, "Pad ascii art with blank characters until\nits height is a multiple of passed value\ne.g. --height-multiple 8\n")
+	rootCmd.PersistentFlags().IntSliceVar(&blendBgColor, "blend-bg", nil, "Blend semi-transparent pixels over this\ncolor before conversion\nPass an RGB value\ne.g. --blend-bg 255,255,255\n(Defaults to 0,0,0)\n")
+	rootCmd.PersistentFlags().BoolVar(&embedMetadata, "embed-metadata", false, "Embed source, creation time and flags used\nas text chunks in --save-img file\n")
+	rootCmd.PersistentFlags().BoolVar(&blink, "blink-brightest", false, "Make the brightest characters blink\n")
+	rootCmd.PersistentFlags().BoolVar(&conceal, "conceal-darkest", false, "Hide the darkest characters, keeping\ntheir space\n")
+	rootCmd.PersistentFlags().BoolVar(&singleLine, "single-line", false, "Print ascii art as one line with newlines\nand color codes escaped, e.g. for logs\n(Not applicable for gifs)\n")
+	rootCmd.PersistentFlags().BoolVar(&premultiplied, "premultiplied", false, "Write premultiplied alpha colors to\n--save-img file instead of straight colors\n")
+	rootCmd.PersistentFlags().IntVar(&outputDPI, "dpi", 0, "Print resolution of --save-img file in dots\nper inch, e.g. --dpi 300\n")
+	rootCmd.PersistentFlags().BoolVar(&matchSource, "match-source", false, "Size --save-img file to the width and\nheight of the original image\n")
+	rootCmd.PersistentFlags().StringVar(&paperSize, "paper", "", "Scale --save-img file to fill a sheet of\npaper printed at --dpi resolution\nOne of a3, a4, a5, letter or legal\ne.g. --paper a4\n")
 	rootCmd.PersistentFlags().StringVar(&saveTxtPath, "save-txt", "", "Save ascii art as a .txt file\nFormat: <image-name>-ascii-art.txt\nFile will be saved in passed path\n(pass . for current directory)\n")
 	rootCmd.PersistentFlags().StringVar(&saveGifPath, "save-gif", "", "If input is a gif, save it as a .gif file\nFormat: <gif-name>-ascii-art.gif\nGif will be saved in passed path\n(pass . for current directory)\n")
+	rootCmd.PersistentFlags().Float64Var(&targetFPS, "fps", 0, "Resample gif frames to a constant frame rate\nfor display and --save-gif flag\ne.g. --fps 15 (Maximum of 100)\n(Only applicable for gifs)\n")
+	rootCmd.PersistentFlags().IntVar(&minFrameDelay, "min-delay", 0, "Minimum frame delay for --save-gif flag\nin 100ths of a second\nBrowsers usually slow down delays below 2\ne.g. --min-delay 2\n")
+	rootCmd.PersistentFlags().StringVar(&saveMoviePath, "save-movie", "", "If input is a gif, save its frames with\nterminal colors as an ansi movie file\nFormat: <gif-name>-ascii-art.ansi\nFile will be saved in passed path\n(pass . for current directory)\n")
+	rootCmd.PersistentFlags().StringVar(&scrollDir, "scroll", "", "Animate a viewport moving across an image\nin passed direction, like a gif\nOne of right, left, down or up\ne.g. --scroll right\n")
+	rootCmd.PersistentFlags().IntVar(&scrollFrames, "scroll-frames", 30, "Number of frames for --scroll flag\n")
+	rootCmd.PersistentFlags().IntSliceVar(&scrollView, "scroll-viewport", nil, "Viewport width and height in pixels of\nthe image for --scroll flag\ne.g. --scroll-viewport 400,300\n(Defaults to full width and height)\n")
+	rootCmd.PersistentFlags().BoolVar(&dropShadow, "drop-shadow", false, "Draw a blurred shadow beneath characters\nof --save-img and --save-gif files\n")
+	rootCmd.PersistentFlags().IntSliceVar(&shadowColor, "shadow-color", nil, "RGBA color of --drop-shadow\ne.g. --shadow-color 0,0,0,60\n(Defaults to 0,0,0,60)\n")
+	rootCmd.PersistentFlags().IntSliceVar(&shadowOffset, "shadow-offset", nil, "Offset of --drop-shadow in pixels\ne.g. --shadow-offset 4,4\n(Defaults to 4,4)\n")
 	rootCmd.PersistentFlags().IntSliceVar(&saveBgColor, "save-bg", nil, "Set background color for --save-img\nand --save-gif flags\nPass an RGBA value\ne.g. --save-bg 255,255,255,100\n(Defaults to 0,0,0,100)\n")
 	rootCmd.PersistentFlags().StringVar(&fontFile, "font", "", "Set font for --save-img and --save-gif flags\nPass file path to font .ttf file\ne.g. --font ./RobotoMono-Regular.ttf\n(Defaults to Hack-Regular for ascii and\n DejaVuSans-Oblique for braille)\n")
 	rootCmd.PersistentFlags().IntSliceVar(&fontColor, "font-color", nil, "Set font color for terminal as well as\n--save-img and --save-gif flags\nPass an RGB value\ne.g. --font-color 0,0,0\n(Defaults to 255,255,255)\n")
+	rootCmd.PersistentFlags().StringVar(&cellTemplate, "cell-template", "", "Go text/template to print for each character\nFields: .Char .R .G .B .Col .Row\ne.g. --cell-template \"{{.Char}},{{.R}};\"\n(Only applicable for images)\n")
+	rootCmd.PersistentFlags().StringVar(&rowSeparator, "row-sep", "", "Separator between rows for --cell-template\n(Defaults to a newline)\n")
 	rootCmd.PersistentFlags().BoolVar(&onlySave, "only-save", false, "Don't print ascii art on terminal\nif some saving flag is passed\n")
+	rootCmd.PersistentFlags().BoolVar(&assumeGray, "assume-gray", false, "Treat input as already grayscale to skip\ncolor extraction for faster conversion\n(Can't be used with --color flag)\n")
+	rootCmd.PersistentFlags().BoolVar(&saveCursor, "restore-cursor", false, "Return cursor to its position before\nprinting ascii art, redrawing gifs in place\n(Only applicable for terminal display)\n")
+	rootCmd.PersistentFlags().StringVar(&decodeModel, "decode-model", "nrgba", "Color model to convert decoded image into\nPass either rgba (premultiplied alpha)\nor nrgba (straight alpha)\ne.g. --decode-model rgba\n(Defaults to nrgba)\n")
+	rootCmd.PersistentFlags().BoolVar(&useThumbnail, "thumbnail", false, "Convert embedded EXIF thumbnail of jpegs\ninstead of full image, if it's large enough\n")
+	rootCmd.PersistentFlags().BoolVar(&streamDecode, "stream-decode", false, "Decode large tiff images row by row\nwithout holding them fully in memory\n(Only applicable for uncompressed tiffs)\n")
+	rootCmd.PersistentFlags().BoolVar(&allowPartial, "allow-partial", false, "Convert the decodable rows of truncated\njpeg and png images instead of failing\n")
 	rootCmd.PersistentFlags().BoolVar(&formatsTrue, "formats", false, "Display supported input formats\n")
 
 	rootCmd.PersistentFlags().BoolP("help", "h", false, "Help for "+rootCmd.Name()+"\n")
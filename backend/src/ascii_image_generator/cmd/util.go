@@ -19,6 +19,11 @@ package cmd
 import (
 	"fmt"
 	"path"
+	"strconv"
+	"strings"
+
+	"github.com/TheZoraiz/ascii-image-converter/aic_package"
+	"github.com/spf13/cobra"
 )
 
 // Check input and flag values for detecting errors or invalid inputs
@@ -32,7 +37,8 @@ func checkInputAndFlags(args []string) bool {
 	for _, arg := range args {
 		extension := path.Ext(arg)
 
-		if extension == ".gif" {
+		// Gifs are converted like other images with --static flag
+		if extension == ".gif" && !forceStatic {
 			gifPresent = true
 			gifCount++
 		} else {
@@ -49,6 +55,11 @@ func checkInputAndFlags(args []string) bool {
 		return true
 	}
 
+	if scrollDir != "" && len(args) > 1 && !onlySave {
+		fmt.Printf("Error: There are multiple inputs supplied with --scroll flag\nDue to the looping nature of scroll animations, only one input per command is supported\n\n")
+		return true
+	}
+
 	if gifCount > 1 && !onlySave {
 		fmt.Printf("Error: There are multiple GIFs supplied\nDue to the potential looping nature of GIFs, only one GIF per command is supported\n\n")
 		return true
@@ -80,6 +91,85 @@ func checkInputAndFlags(args []string) bool {
 		return true
 	}
 
+	if rampPreset != "" && rampPreset != "shade" {
+		fmt.Printf("Error: --ramp only supports shade\n\n")
+		return true
+	}
+
+	if maxGlyphs < 0 || maxGlyphs == 1 {
+		fmt.Printf("Error: --max-glyphs must be at least 2\n\n")
+		return true
+	}
+
+	if minFrameDelay < 0 {
+		fmt.Printf("Error: --min-delay can't be negative\n\n")
+		return true
+	}
+
+	if targetFPS < 0 {
+		fmt.Printf("Error: --fps can't be negative\n\n")
+		return true
+	}
+
+	if targetFPS > 100 {
+		fmt.Printf("Error: --fps can't be above 100\n\n")
+		return true
+	}
+
+	if scrollDir != "" {
+		switch scrollDir {
+		case "right", "left", "down", "up":
+		default:
+			fmt.Printf("Error: --scroll must be one of right, left, down or up\n\n")
+			return true
+		}
+
+		if scrollFrames < 1 {
+			fmt.Printf("Error: --scroll-frames must be at least 1\n\n")
+			return true
+		}
+
+		if scrollView == nil {
+			scrollView = []int{0, 0}
+		} else if len(scrollView) != 2 {
+			fmt.Printf("Error: --scroll-viewport requires 2 values for width and height, got %v\n\n", len(scrollView))
+			return true
+		} else if scrollView[0] < 0 || scrollView[1] < 0 {
+			fmt.Printf("Error: --scroll-viewport values can't be negative\n\n")
+			return true
+		}
+	}
+
+	if wrapAt < 0 {
+		fmt.Printf("Error: --wrap can't be negative\n\n")
+		return true
+	}
+
+	if edgeThreshold < 0 {
+		fmt.Printf("Error: --edge-threshold can't be negative\n\n")
+		return true
+	}
+
+	if widthMult < 0 || heightMult < 0 {
+		fmt.Printf("Error: --width-multiple and --height-multiple can't be negative\n\n")
+		return true
+	}
+
+	if vignette < 0 || vignette > 1 {
+		fmt.Printf("Error: --vignette must be from 0 to 1\n\n")
+		return true
+	}
+
+	if timeBudget < 0 {
+		fmt.Printf("Error: --time-budget can't be negative\n\n")
+		return true
+	}
+
+	if startTime < 0 || clipDuration < 0 {
+		fmt.Printf("Error: --start and --duration can't be negative\n\n")
+		return true
+	}
+
 	if dimensions != nil {
 
 		numberOfDimensions := len(dimensions)
@@ -158,6 +248,124 @@ func checkInputAndFlags(args []string) bool {
 		}
 	}
 
+	if brailleFull && !braille {
+		fmt.Printf("Error: --braille-full requires --braille flag\n\n")
+		return true
+	}
+
+	if brailleFull && (colorBg || separated) {
+		fmt.Printf("Error: --braille-full can't be used with --color-bg or --separate flags\n\n")
+		return true
+	}
+
+	if separated && !braille && dotMode == "" {
+		fmt.Printf("Error: --separate requires --braille or --dot-mode flag\n\n")
+		return true
+	}
+
+	if separated && assumeGray {
+		fmt.Printf("Error: --separate can't be used with --assume-gray flag\n\n")
+		return true
+	}
+
+	if channelLimits == nil {
+		channelLimits = []int{128, 128, 128}
+	} else if len(channelLimits) != 3 {
+		fmt.Printf("Error: --channel-thresholds requires 3 values for RGB, got %v\n\n", len(channelLimits))
+		return true
+	} else if channelLimits[0] < 0 || channelLimits[1] < 0 || channelLimits[2] < 0 ||
+		channelLimits[0] > 255 || channelLimits[1] > 255 || channelLimits[2] > 255 {
+		fmt.Printf("Error: --channel-thresholds values must be between 0 and 255\n\n")
+		return true
+	}
+
+	if theme16 != nil {
+		if !color16 {
+			fmt.Printf("Error: --palette16 requires --color16 flag\n\n")
+			return true
+		}
+
+		if len(theme16) != 16 {
+			fmt.Printf("Error: --palette16 requires 16 hex colors, got %v\n\n", len(theme16))
+			return true
+		}
+
+		for i, hexColor := range theme16 {
+			hexColor = strings.TrimPrefix(hexColor, "#")
+			value, err := strconv.ParseUint(hexColor, 16, 32)
+			if len(hexColor) != 6 || err != nil {
+				fmt.Printf("Error: %v is not a hex color like ff5555 for --palette16\n\n", theme16[i])
+				return true
+			}
+			theme16Colors[i] = [3]int{int(value >> 16), int(value >> 8 & 0xff), int(value & 0xff)}
+		}
+	}
+
+	if shadowColor == nil {
+		shadowColor = []int{0, 0, 0, 60}
+	} else {
+		if len(shadowColor) != 4 {
+			fmt.Printf("Error: --shadow-color requires 4 values for RGBA, got %v\n\n", len(shadowColor))
+			return true
+		}
+
+		if shadowColor[0] < 0 || shadowColor[1] < 0 || shadowColor[2] < 0 || shadowColor[3] < 0 ||
+			shadowColor[0] > 255 || shadowColor[1] > 255 || shadowColor[2] > 255 || shadowColor[3] > 100 {
+			fmt.Printf("Error: RBG values must be between 0 and 255\n")
+			fmt.Printf("Error: Opacity value must be between 0 and 100\n\n")
+			return true
+		}
+	}
+
+	if shadowOffset == nil {
+		shadowOffset = []int{4, 4}
+	} else if len(shadowOffset) != 2 {
+		fmt.Printf("Error: --shadow-offset requires 2 values for x and y, got %v\n\n", len(shadowOffset))
+		return true
+	}
+
+	if termSize == nil {
+		termSize = []int{0, 0}
+	} else if len(termSize) != 2 {
+		fmt.Printf("Error: --term-size requires 2 values for width and height, got %v\n\n", len(termSize))
+		return true
+	} else if termSize[0] < 0 || termSize[1] < 0 {
+		fmt.Printf("Error: --term-size values can't be negative\n\n")
+		return true
+	}
+
+	if termBgColor == nil {
+		termBgColor = []int{-1, -1, -1}
+	} else {
+		if len(termBgColor) != 3 {
+			fmt.Printf("Error: --term-bg requires 3 values for RGB, got %v\n\n", len(termBgColor))
+			return true
+		}
+
+		for _, value := range termBgColor {
+			if value < 0 || value > 255 {
+				fmt.Printf("Error: RGB values must be between 0 and 255\n\n")
+				return true
+			}
+		}
+	}
+
+	if blendBgColor == nil {
+		blendBgColor = []int{0, 0, 0}
+	} else {
+		if len(blendBgColor) != 3 {
+			fmt.Printf("Error: --blend-bg requires 3 values for RGB, got %v\n\n", len(blendBgColor))
+			return true
+		}
+
+		for _, value := range blendBgColor {
+			if value < 0 || value > 255 {
+				fmt.Printf("Error: RBG values must be between 0 and 255\n\n")
+				return true
+			}
+		}
+	}
+
 	if threshold == 0 {
 		threshold = 128
 	}
@@ -167,15 +375,153 @@ func checkInputAndFlags(args []string) bool {
 		return true
 	}
 
-	if dither && !braille {
-		fmt.Printf("Error: image dithering is only reserved for --braille flag\n\n")
+	if dotMode != "" && dotMode != "legacy2x2" {
+		fmt.Printf("Error: --dot-mode only supports legacy2x2\n\n")
+		return true
+	}
+
+	if dotMode != "" && braille {
+		fmt.Printf("Error: --dot-mode can't be used with --braille flag\n\n")
+		return true
+	}
+
+	if dither && !braille && dotMode == "" {
+		fmt.Printf("Error: image dithering is only reserved for --braille and --dot-mode flags\n\n")
+		return true
+	}
+
+	if outputDPI < 0 {
+		fmt.Printf("Error: --dpi can't be negative\n\n")
+		return true
+	}
+
+	switch paperSize {
+	case "", "a3", "a4", "a5", "letter", "legal":
+	default:
+		fmt.Printf("Error: --paper must be one of a3, a4, a5, letter or legal\n\n")
+		return true
+	}
+
+	if paperSize != "" && outputDPI == 0 {
+		fmt.Printf("Error: --paper requires --dpi flag\n\n")
 		return true
 	}
 
-	if (saveTxtPath == "" && saveImagePath == "" && saveGifPath == "") && onlySave {
-		fmt.Printf("Error: you need to supply one of --save-img, --save-txt or --save-gif for using --only-save\n\n")
+	if matchSource && paperSize != "" {
+		fmt.Printf("Error: --match-source can't be used with --paper flag\n\n")
+		return true
+	}
+
+	if preset != "" {
+		validPreset := false
+		for _, name := range aic_package.Presets() {
+			if name == preset {
+				validPreset = true
+			}
+		}
+
+		if !validPreset {
+			fmt.Printf("Error: --flag-preset must be one of %v\n\n", strings.Join(aic_package.Presets(), ", "))
+			return true
+		}
+	}
+
+	if mosaic && (braille || dotMode != "") {
+		fmt.Printf("Error: --mosaic can't be used with --braille or --dot-mode flags\n\n")
+		return true
+	}
+
+	if mosaic && assumeGray {
+		fmt.Printf("Error: --mosaic can't be used with --assume-gray flag\n\n")
+		return true
+	}
+
+	if binary && (braille || dotMode != "" || mosaic) {
+		fmt.Printf("Error: --binary can't be used with --braille, --dot-mode or --mosaic flags\n\n")
+		return true
+	}
+
+	if adaptive && !braille && dotMode == "" {
+		fmt.Printf("Error: --adaptive is only reserved for --braille and --dot-mode flags\n\n")
+		return true
+	}
+
+	if stableNoise && !dither {
+		fmt.Printf("Error: --stable-noise requires --dither flag\n\n")
+		return true
+	}
+
+	if adaptive && dither {
+		fmt.Printf("Error: --adaptive can't be used with --dither flag\n\n")
+		return true
+	}
+
+	if adaptive && adaptiveWin < 3 {
+		fmt.Printf("Error: --adaptive-window must be at least 3\n\n")
+		return true
+	}
+
+	if assumeGray && colored {
+		fmt.Printf("Error: --assume-gray can't be used with --color flag\n\n")
+		return true
+	}
+
+	if decodeModel != "rgba" && decodeModel != "nrgba" {
+		fmt.Printf("Error: --decode-model must be either rgba or nrgba\n\n")
+		return true
+	}
+
+	switch lumaChannel {
+	case "luma", "r", "g", "b", "alpha", "max", "min":
+	default:
+		fmt.Printf("Error: --channel must be one of luma, r, g, b, alpha, max or min\n\n")
+		return true
+	}
+
+	if (saveTxtPath == "" && saveImagePath == "" && saveGifPath == "" && saveMoviePath == "") && onlySave {
+		fmt.Printf("Error: you need to supply one of --save-img, --save-txt, --save-gif or --save-movie for using --only-save\n\n")
 		return true
 	}
 
 	return false
 }
+
+// Copies the fields that presets set from each CLI flag, keyed by flag name, so that passed flags aren't overwritten by --flag-preset
+var presetFields = map[string]func(flags *aic_package.Flags, passed aic_package.Flags){
+	"color":      func(flags *aic_package.Flags, passed aic_package.Flags) { flags.Colored = passed.Colored },
+	"grayscale":  func(flags *aic_package.Flags, passed aic_package.Flags) { flags.Grayscale = passed.Grayscale },
+	"font-color": func(flags *aic_package.Flags, passed aic_package.Flags) { flags.FontColor = passed.FontColor },
+	"save-bg": func(flags *aic_package.Flags, passed aic_package.Flags) {
+		flags.SaveBackgroundColor = passed.SaveBackgroundColor
+	},
+	"vignette": func(flags *aic_package.Flags, passed aic_package.Flags) { flags.Vignette = passed.Vignette },
+	"braille":  func(flags *aic_package.Flags, passed aic_package.Flags) { flags.Braille = passed.Braille },
+	"dither":   func(flags *aic_package.Flags, passed aic_package.Flags) { flags.Dither = passed.Dither },
+	"complex":  func(flags *aic_package.Flags, passed aic_package.Flags) { flags.Complex = passed.Complex },
+	"decode-model": func(flags *aic_package.Flags, passed aic_package.Flags) {
+		flags.DecodeColorModel = passed.DecodeColorModel
+	},
+	"ramp": func(flags *aic_package.Flags, passed aic_package.Flags) { flags.RampPreset = passed.RampPreset },
+	"map":  func(flags *aic_package.Flags, passed aic_package.Flags) { flags.CustomMap = passed.CustomMap },
+	"adaptive": func(flags *aic_package.Flags, passed aic_package.Flags) {
+		flags.AdaptiveThreshold = passed.AdaptiveThreshold
+	},
+	"adaptive-window": func(flags *aic_package.Flags, passed aic_package.Flags) { flags.AdaptiveWindow = passed.AdaptiveWindow },
+	"edges":           func(flags *aic_package.Flags, passed aic_package.Flags) { flags.DetailOverlay = passed.DetailOverlay },
+	"edge-threshold":  func(flags *aic_package.Flags, passed aic_package.Flags) { flags.EdgeThreshold = passed.EdgeThreshold },
+}
+
+// Applies the preset passed through --flag-preset over flags, keeping the values of flags that were explicitly passed
+func applyPreset(cmd *cobra.Command, flags aic_package.Flags) aic_package.Flags {
+
+	// Name is already checked by checkInputAndFlags()
+	presetFlags, _ := aic_package.ApplyPreset(preset, flags)
+
+	for name, keepPassed := range presetFields {
+		if cmd.Flags().Changed(name) {
+			keepPassed(&presetFlags, flags)
+		}
+	}
+
+	return presetFlags
+}
@@ -16,6 +16,8 @@ limitations under the License.
 
 package image_conversions
 
+import "math"
+
 var (
 	// Reference taken from http://paulbourke.net/dataformats/asciiart/
 	asciiTableSimple   = " .:-=+*#%@"
@@ -29,9 +31,36 @@ var (
 		{0x40, 0x80},
 	}
 
-	BrailleThreshold uint32
+	// Block characters for each combination of highlighted quadrants, indexed by a bitmask
+	// of upper left (1), upper right (2), lower left (4) and lower right (8) quadrants
+	QuadrantChars = [16]string{
+		" ", "▘", "▝", "▀", "▖", "▌", "▞", "▛",
+		"▗", "▚", "▐", "▜", "▄", "▙", "▟", "█",
+	}
+
+	// Shaded block characters that ConvertToQuadrantChars() picks from along with QuadrantChars, with
+	// the coverage of their upper left, upper right, lower left and lower right quadrants. Apart from
+	// the three shades of Block Elements, they're from Symbols for Legacy Computing (U+1FB00 to U+1FBFF)
+	LegacyShadeChars = []BlockGlyph{
+		{"░", [4]float64{0.25, 0.25, 0.25, 0.25}},
+		{"▒", [4]float64{0.5, 0.5, 0.5, 0.5}},
+		{"▓", [4]float64{0.75, 0.75, 0.75, 0.75}},
+		{"\U0001FB8C", [4]float64{0.5, 0, 0.5, 0}}, // Left half medium shade
+		{"\U0001FB8D", [4]float64{0, 0.5, 0, 0.5}}, // Right half medium shade
+		{"\U0001FB8E", [4]float64{0.5, 0.5, 0, 0}}, // Upper half medium shade
+		{"\U0001FB8F", [4]float64{0, 0, 0.5, 0.5}}, // Lower half medium shade
+		{"\U0001FB91", [4]float64{1, 1, 0.5, 0.5}}, // Upper half block and lower half inverse medium shade
+		{"\U0001FB92", [4]float64{0.5, 0.5, 1, 1}}, // Upper half inverse medium shade and lower half block
+		{"\U0001FB94", [4]float64{0.5, 1, 0.5, 1}}, // Left half inverse medium shade and right half block
+	}
 )
 
+// A block character along with the portion of each of its quadrants it fills, from 0 to 1
+type BlockGlyph struct {
+	Char     string
+	Coverage [4]float64
+}
+
 // For each individual element of imgSet in ConvertToASCIISlice()
 const MAX_VAL float64 = 255
 
@@ -40,6 +69,25 @@ type AsciiChar struct {
 	SetColor      string
 	Simple        string
 	RgbValue      [3]uint32
+
+	// Index of Simple in the chosen character set, 0 being the darkest.
+	// For braille characters, this is the bitmask of highlighted dots instead
+	Index int
+}
+
+// Further settings of ConvertToAsciiChars(). The zero value keeps its original behavior
+type CharOptions struct {
+	// If greater than 1 and smaller than the chosen character set, the set is reduced to
+	// that many evenly spaced characters before comparison
+	MaxGlyphs int
+
+	// Detect edges in imgSet as well, and replace characters where edge strength is above
+	// EdgeThreshold with one of the edge characters "-", "|", "/" and "\", depending upon
+	// edge direction. Colors of replaced characters are kept
+	DetailOverlay bool
+
+	// Edge strength above which DetailOverlay replaces a character
+	EdgeThreshold int
 }
 
 /*
@@ -48,35 +96,15 @@ to a 2D image_conversions.AsciiChar slice
 
 If complex parameter is true, values are compared to 70 levels of color density in ASCII characters.
 Otherwise, values are compared to 10 levels of color density in ASCII characters.
+
+Settings added on top of the original arguments are described for CharOptions
 */
-func ConvertToAsciiChars(imgSet [][]AsciiPixel, negative, colored, grayscale, complex, colorBg bool, customMap string, fontColor [3]int) ([][]AsciiChar, error) {
+func ConvertToAsciiChars(imgSet [][]AsciiPixel, negative, colored, grayscale, complex, colorBg bool, customMap string, fontColor [3]int, opts CharOptions) ([][]AsciiChar, error) {
 
 	height := len(imgSet)
 	width := len(imgSet[0])
 
-	chosenTable := map[int]string{}
-
-	// Turn ascii character-set string into map[int]string{} literal
-	if customMap == "" {
-		var charSet string
-
-		if complex {
-			charSet = asciiTableDetailed
-		} else {
-			charSet = asciiTableSimple
-		}
-
-		for index, char := range charSet {
-			chosenTable[index] = string(char)
-		}
-
-	} else {
-		chosenTable = map[int]string{}
-
-		for index, char := range customMap {
-			chosenTable[index] = string(char)
-		}
-	}
+	chosenTable := GetCharacterTable(complex, customMap, opts.MaxGlyphs)
 
 	var result [][]AsciiChar
 
@@ -125,7 +153,13 @@ func ConvertToAsciiChars(imgSet [][]AsciiPixel, negative, colored, grayscale, co
 			var char AsciiChar
 
 			asciiChar := chosenTable[tempInt]
+			if opts.DetailOverlay {
+				if strength, edgeChar := getEdgeChar(imgSet, i, j); strength > float64(opts.EdgeThreshold) {
+					asciiChar = edgeChar
+				}
+			}
 			char.Simple = asciiChar
+			char.Index = tempInt
 
 			var err error
 			if colorBg {
@@ -175,8 +209,6 @@ Unlike ConvertToAsciiChars(), this function calculates braille characters instea
 */
 func ConvertToBrailleChars(imgSet [][]AsciiPixel, negative, colored, grayscale, colorBg bool, fontColor [3]int, threshold int) ([][]AsciiChar, error) {
 
-	BrailleThreshold = uint32(threshold)
-
 	height := len(imgSet)
 	width := len(imgSet[0])
 
@@ -188,7 +220,7 @@ func ConvertToBrailleChars(imgSet [][]AsciiPixel, negative, colored, grayscale,
 
 		for j := 0; j < width; j += 2 {
 
-			brailleChar := getBrailleChar(i, j, negative, imgSet)
+			brailleChar := getBrailleChar(i, j, negative, imgSet, uint32(threshold))
 
 			var r, g, b int
 
@@ -218,6 +250,7 @@ func ConvertToBrailleChars(imgSet [][]AsciiPixel, negative, colored, grayscale,
 			var char AsciiChar
 
 			char.Simple = brailleChar
+			char.Index = int([]rune(brailleChar)[0] - 0x2800)
 
 			var err error
 			if colorBg {
@@ -260,24 +293,400 @@ func ConvertToBrailleChars(imgSet [][]AsciiPixel, negative, colored, grayscale,
 	return result, nil
 }
 
+/*
+Like ConvertToBrailleChars(), except that each character gets two colors instead of one. The foreground is the
+average color of the pixels that became highlighted dots, and the background is the average color of the rest of
+the pixels in its cell, so both halves of the cell keep their own color like they would with half blocks. If all
+or none of the dots are highlighted, both colors are the average of the whole cell.
+
+If grayscale is true, grayscale values of pixels are used instead of their colors. If negative is true, dots are
+chosen from the opposite side of the threshold and colors are inverted. RgbValue of each character is its
+foreground color
+*/
+func ConvertToBrailleColorFullChars(imgSet [][]AsciiPixel, negative, grayscale bool, threshold int) ([][]AsciiChar, error) {
+
+	dotThreshold := uint32(threshold)
+
+	height := len(imgSet)
+	width := len(imgSet[0])
+
+	var result [][]AsciiChar
+
+	for i := 0; i+3 < height; i += 4 {
+
+		var tempSlice []AsciiChar
+
+		for j := 0; j+1 < width; j += 2 {
+
+			var (
+				dots     int
+				onSum    [3]int
+				offSum   [3]int
+				onCount  int
+				offCount int
+			)
+
+			for y := 0; y < 4; y++ {
+				for x := 0; x < 2; x++ {
+					pixel := imgSet[i+y][j+x]
+
+					value := pixel.rgbValue
+					if grayscale {
+						value = pixel.grayscaleValue
+					}
+
+					highlighted := pixel.charDepth >= dotThreshold
+					if negative {
+						highlighted = pixel.charDepth <= dotThreshold
+					}
+
+					for c := 0; c < 3; c++ {
+						channel := int(value[c])
+						if negative {
+							channel = 255 - channel
+						}
+
+						if highlighted {
+							onSum[c] += channel
+						} else {
+							offSum[c] += channel
+						}
+					}
+
+					if highlighted {
+						dots += BrailleStruct[y][x]
+						onCount++
+					} else {
+						offCount++
+					}
+				}
+			}
+
+			var fg, bg [3]uint8
+			for c := 0; c < 3; c++ {
+				if onCount == 0 || offCount == 0 {
+					fg[c] = uint8((onSum[c] + offSum[c]) / 8)
+					bg[c] = fg[c]
+				} else {
+					fg[c] = uint8(onSum[c] / onCount)
+					bg[c] = uint8(offSum[c] / offCount)
+				}
+			}
+
+			brailleChar := string(rune(0x2800 + dots))
+
+			var char AsciiChar
+
+			char.Simple = brailleChar
+			char.Index = dots
+			char.RgbValue = [3]uint32{uint32(fg[0]), uint32(fg[1]), uint32(fg[2])}
+
+			var err error
+			char.OriginalColor, err = getDualColoredCharForTerm(fg, bg, brailleChar)
+			if err != nil {
+				return nil, err
+			}
+
+			tempSlice = append(tempSlice, char)
+		}
+
+		result = append(result, tempSlice)
+	}
+
+	return result, nil
+}
+
 // Iterate through the BrailleStruct table to see which dots need to be highlighted
-func getBrailleChar(x, y int, negative bool, imgSet [][]AsciiPixel) string {
+func getBrailleChar(x, y int, negative bool, imgSet [][]AsciiPixel, threshold uint32) string {
 
 	brailleChar := 0x2800
 
 	for i := 0; i < 4; i++ {
 		for j := 0; j < 2; j++ {
 			if negative {
-				if imgSet[x+i][y+j].charDepth <= BrailleThreshold {
+				if imgSet[x+i][y+j].charDepth <= threshold {
 					brailleChar += BrailleStruct[i][j]
 				}
 			} else {
-				if imgSet[x+i][y+j].charDepth >= BrailleThreshold {
+				if imgSet[x+i][y+j].charDepth >= threshold {
 					brailleChar += BrailleStruct[i][j]
 				}
 			}
 		}
 	}
 
-	return string(brailleChar)
+	return string(rune(brailleChar))
+}
+
+/*
+Returns the character set used by ConvertToAsciiChars(), mapped from darkest (0) to lightest character.
+This is customMap if it's not empty, otherwise the detailed or simple table depending on complex.
+The set is reduced to maxGlyphs characters as described for CharOptions.MaxGlyphs
+*/
+func GetCharacterTable(complex bool, customMap string, maxGlyphs int) map[int]string {
+
+	chosenTable := map[int]string{}
+
+	// Turn ascii character-set string into map[int]string{} literal
+	if customMap == "" {
+		var charSet string
+
+		if complex {
+			charSet = asciiTableDetailed
+		} else {
+			charSet = asciiTableSimple
+		}
+
+		for index, char := range []rune(charSet) {
+			chosenTable[index] = string(char)
+		}
+
+	} else {
+		// Indexed by rune rather than byte, so that multi-byte characters are mapped correctly
+		for index, char := range []rune(customMap) {
+			chosenTable[index] = string(char)
+		}
+	}
+
+	if maxGlyphs > 1 && maxGlyphs < len(chosenTable) {
+		chosenTable = subsampleTable(chosenTable, maxGlyphs)
+	}
+
+	return chosenTable
+}
+
+// Picks glyphCount evenly spaced characters from table, always keeping its darkest and lightest characters
+func subsampleTable(table map[int]string, glyphCount int) map[int]string {
+
+	subsampled := map[int]string{}
+	last := len(table) - 1
+
+	for i := 0; i < glyphCount; i++ {
+		index := int(math.Round(float64(i*last) / float64(glyphCount-1)))
+		subsampled[i] = table[index]
+	}
+
+	return subsampled
+}
+
+/*
+Converts the 2D image_conversions.AsciiPixel slice of image data (each instance representing each compressed pixel of original image)
+to a 2D image_conversions.AsciiChar slice
+
+Like ConvertToBrailleChars(), each character covers multiple pixels, but each character is a block character
+made up of a 2x2 grid of quadrants instead. The brightness of each pixel is taken as the coverage of its quadrant,
+and the character of QuadrantChars or LegacyShadeChars whose coverage is closest to it is picked, by the sum of
+squared differences. Indexes of QuadrantChars are the bitmask of their filled quadrants, while LegacyShadeChars
+follow from 16 onwards. Pixels that are already thresholded, e.g. through dithering, always map to QuadrantChars
+*/
+func ConvertToQuadrantChars(imgSet [][]AsciiPixel, negative, colored, grayscale, colorBg bool, fontColor [3]int) ([][]AsciiChar, error) {
+
+	height := len(imgSet)
+	width := len(imgSet[0])
+
+	var result [][]AsciiChar
+
+	for i := 0; i+1 < height; i += 2 {
+
+		var tempSlice []AsciiChar
+
+		for j := 0; j+1 < width; j += 2 {
+
+			var coverage [4]float64
+			for k, pixel := range [4]AsciiPixel{imgSet[i][j], imgSet[i][j+1], imgSet[i+1][j], imgSet[i+1][j+1]} {
+				coverage[k] = float64(pixel.charDepth) / MAX_VAL
+				if negative {
+					coverage[k] = 1 - coverage[k]
+				}
+			}
+			quadrantChar, index := closestBlockChar(coverage)
+
+			var r, g, b int
+
+			if colored {
+				r = int(imgSet[i][j].rgbValue[0])
+				g = int(imgSet[i][j].rgbValue[1])
+				b = int(imgSet[i][j].rgbValue[2])
+			} else {
+				r = int(imgSet[i][j].grayscaleValue[0])
+				g = int(imgSet[i][j].grayscaleValue[1])
+				b = int(imgSet[i][j].grayscaleValue[2])
+			}
+
+			if negative {
+				r = 255 - r
+				g = 255 - g
+				b = 255 - b
+
+				if colored {
+					imgSet[i][j].rgbValue = [3]uint32{uint32(r), uint32(g), uint32(b)}
+				} else {
+					imgSet[i][j].grayscaleValue = [3]uint32{uint32(r), uint32(g), uint32(b)}
+				}
+			}
+
+			var char AsciiChar
+
+			char.Simple = quadrantChar
+			char.Index = index
+
+			var err error
+			char.OriginalColor, err = getColoredCharForTerm(uint8(r), uint8(g), uint8(b), quadrantChar, colorBg)
+			if (colored || grayscale) && err != nil {
+				return nil, err
+			}
+
+			// If font color is not set, use a simple string. Otherwise, use True color
+			if fontColor != [3]int{255, 255, 255} {
+				char.SetColor, err = getColoredCharForTerm(uint8(fontColor[0]), uint8(fontColor[1]), uint8(fontColor[2]), quadrantChar, colorBg)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if colored {
+				char.RgbValue = imgSet[i][j].rgbValue
+			} else {
+				char.RgbValue = imgSet[i][j].grayscaleValue
+			}
+
+			tempSlice = append(tempSlice, char)
+		}
+
+		result = append(result, tempSlice)
+	}
+
+	return result, nil
+}
+
+// Returns the character of QuadrantChars or LegacyShadeChars closest to coverage, along with its index as
+// described for ConvertToQuadrantChars(). Ties keep the earlier character, so QuadrantChars win them
+func closestBlockChar(coverage [4]float64) (string, int) {
+
+	distance := func(glyph [4]float64) float64 {
+		var sum float64
+		for k := range coverage {
+			sum += (coverage[k] - glyph[k]) * (coverage[k] - glyph[k])
+		}
+		return sum
+	}
+
+	bestIndex := 0
+	bestDistance := math.Inf(1)
+
+	for quadrants := range QuadrantChars {
+		var glyph [4]float64
+		for k := range glyph {
+			if quadrants&(1<<k) != 0 {
+				glyph[k] = 1
+			}
+		}
+		if d := distance(glyph); d < bestDistance {
+			bestIndex, bestDistance = quadrants, d
+		}
+	}
+
+	for i, shade := range LegacyShadeChars {
+		if d := distance(shade.Coverage); d < bestDistance {
+			bestIndex, bestDistance = len(QuadrantChars)+i, d
+		}
+	}
+
+	if bestIndex < len(QuadrantChars) {
+		return QuadrantChars[bestIndex], bestIndex
+	}
+	return LegacyShadeChars[bestIndex-len(QuadrantChars)].Char, bestIndex
+}
+
+/*
+Like ConvertToBrailleChars() and ConvertToQuadrantChars(), each character covers a cellWidth x cellHeight
+grid of pixels, which is 2x4 for braille characters and 2x2 for quadrant block characters. Instead of
+comparing charDepth against one threshold, each color channel of a pixel is compared against its own
+value of thresholds, which results in a dot pattern for each channel. A dot is highlighted wherever any
+of the patterns has it, and the character is colored with each channel in proportion to the highlighted
+dots that belong to its pattern. A pure red cell comes out as a full red character, while a cell with red
+and blue in separate halves mixes into purple, like separations of screen printing.
+
+Characters are always colored, since the color decides which pattern each dot came from. If negative is
+true, channels are inverted before being compared
+*/
+func ConvertToSeparatedDotChars(imgSet [][]AsciiPixel, cellWidth, cellHeight int, negative, colorBg bool, thresholds [3]int) ([][]AsciiChar, error) {
+
+	height := len(imgSet)
+	width := len(imgSet[0])
+
+	var result [][]AsciiChar
+
+	for i := 0; i+cellHeight-1 < height; i += cellHeight {
+
+		var tempSlice []AsciiChar
+
+		for j := 0; j+cellWidth-1 < width; j += cellWidth {
+
+			var (
+				dots          int
+				highlighted   int
+				channelCounts [3]int
+			)
+
+			for y := 0; y < cellHeight; y++ {
+				for x := 0; x < cellWidth; x++ {
+
+					dotHighlighted := false
+					for c := 0; c < 3; c++ {
+						value := int(imgSet[i+y][j+x].rgbValue[c])
+						if negative {
+							value = 255 - value
+						}
+
+						if value >= thresholds[c] {
+							channelCounts[c]++
+							dotHighlighted = true
+						}
+					}
+
+					if dotHighlighted {
+						highlighted++
+						if cellHeight == 4 {
+							dots += BrailleStruct[y][x]
+						} else {
+							dots |= 1 << (y*cellWidth + x)
+						}
+					}
+				}
+			}
+
+			var dotChar string
+			if cellHeight == 4 {
+				dotChar = string(rune(0x2800 + dots))
+			} else {
+				dotChar = QuadrantChars[dots]
+			}
+
+			var rgb [3]uint32
+			if highlighted > 0 {
+				for c := 0; c < 3; c++ {
+					rgb[c] = uint32(255 * channelCounts[c] / highlighted)
+				}
+			}
+
+			var char AsciiChar
+
+			char.Simple = dotChar
+			char.Index = dots
+			char.RgbValue = rgb
+
+			var err error
+			char.OriginalColor, err = getColoredCharForTerm(uint8(rgb[0]), uint8(rgb[1]), uint8(rgb[2]), dotChar, colorBg)
+			if err != nil {
+				return nil, err
+			}
+
+			tempSlice = append(tempSlice, char)
+		}
+
+		result = append(result, tempSlice)
+	}
+
+	return result, nil
 }
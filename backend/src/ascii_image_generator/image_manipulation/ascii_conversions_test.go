@@ -0,0 +1,275 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_conversions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Matches the SGR escape codes that color characters
+var sgrPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// Returns rows x 256 pixels going from black to white along each row
+func newGradientSet(rows int) [][]AsciiPixel {
+	imgSet := make([][]AsciiPixel, rows)
+	for y := range imgSet {
+		imgSet[y] = make([]AsciiPixel, 256)
+		for x := range imgSet[y] {
+			value := uint32(x)
+			imgSet[y][x] = AsciiPixel{
+				charDepth:      value,
+				grayscaleValue: [3]uint32{value, value, value},
+				rgbValue:       [3]uint32{value, 255 - value, value / 2},
+			}
+		}
+	}
+	return imgSet
+}
+
+func TestMaxGlyphsLimitsDistinctRunes(t *testing.T) {
+	theme := [16][3]int{}
+	for i := range theme {
+		theme[i] = [3]int{i * 16, i * 16, i * 16}
+	}
+	Color16Theme = &theme
+	defer func() { Color16Theme = nil }()
+
+	tests := []struct {
+		complex   bool
+		customMap string
+		maxGlyphs int
+		want      int
+	}{
+		{false, "", 0, 10},
+		{false, "", 1, 10},
+		{false, "", 3, 3},
+		{true, "", 5, 5},
+		{true, "", 2, 2},
+		{false, "ab", 5, 2},
+		{false, "░▒▓█", 3, 3},
+	}
+
+	for _, test := range tests {
+		asciiSet, err := ConvertToAsciiChars(newGradientSet(2), false, true, false, test.complex, false, test.customMap, [3]int{255, 255, 255}, CharOptions{MaxGlyphs: test.maxGlyphs})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		distinct := map[rune]bool{}
+		for _, row := range asciiSet {
+			for _, char := range row {
+				for _, r := range sgrPattern.ReplaceAllString(char.OriginalColor, "") {
+					distinct[r] = true
+				}
+			}
+		}
+
+		if len(distinct) != test.want {
+			t.Errorf("complex %v, map %q, max glyphs %v: got %v distinct runes, want %v", test.complex, test.customMap, test.maxGlyphs, len(distinct), test.want)
+		}
+	}
+}
+
+func TestSubsampleTableKeepsEnds(t *testing.T) {
+	table := GetCharacterTable(false, "", 0)
+	subsampled := subsampleTable(table, 4)
+
+	if len(subsampled) != 4 {
+		t.Fatalf("got %v glyphs, want 4", len(subsampled))
+	}
+	if subsampled[0] != table[0] || subsampled[3] != table[len(table)-1] {
+		t.Errorf("got ends %q and %q, want %q and %q", subsampled[0], subsampled[3], table[0], table[len(table)-1])
+	}
+	for i := 1; i < 4; i++ {
+		if subsampled[i] == subsampled[i-1] {
+			t.Errorf("glyph %v repeats %q", i, subsampled[i])
+		}
+	}
+}
+
+// Returns a width x height set of pixels that all have color rgb
+func newUniformSet(width, height int, rgb [3]uint32) [][]AsciiPixel {
+	imgSet := make([][]AsciiPixel, height)
+	for y := range imgSet {
+		imgSet[y] = make([]AsciiPixel, width)
+		for x := range imgSet[y] {
+			imgSet[y][x].rgbValue = rgb
+		}
+	}
+	return imgSet
+}
+
+func TestSeparatedDotsAtThresholdLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		rgb        [3]uint32
+		thresholds [3]int
+		negative   bool
+		wantChar   string
+		wantColor  [3]uint32
+	}{
+		// 0 highlights every dot of its channel, even for black
+		{"red at 0 on black", [3]uint32{0, 0, 0}, [3]int{0, 255, 255}, false, "⣿", [3]uint32{255, 0, 0}},
+		{"all at 0 on black", [3]uint32{0, 0, 0}, [3]int{0, 0, 0}, false, "⣿", [3]uint32{255, 255, 255}},
+		{"all at 0 on black, negative", [3]uint32{0, 0, 0}, [3]int{0, 0, 0}, true, "⣿", [3]uint32{255, 255, 255}},
+
+		// 255 only highlights channels at their full value
+		{"all at 255 on white", [3]uint32{255, 255, 255}, [3]int{255, 255, 255}, false, "⣿", [3]uint32{255, 255, 255}},
+		{"all at 255 just below white", [3]uint32{254, 254, 254}, [3]int{255, 255, 255}, false, "⠀", [3]uint32{0, 0, 0}},
+		{"green at 255 on yellow", [3]uint32{255, 255, 0}, [3]int{255, 255, 255}, false, "⣿", [3]uint32{255, 255, 0}},
+		{"all at 255 on black, negative", [3]uint32{0, 0, 0}, [3]int{255, 255, 255}, true, "⣿", [3]uint32{255, 255, 255}},
+		{"all at 255 on white, negative", [3]uint32{255, 255, 255}, [3]int{255, 255, 255}, true, "⠀", [3]uint32{0, 0, 0}},
+	}
+
+	for _, test := range tests {
+		asciiSet, err := ConvertToSeparatedDotChars(newUniformSet(2, 4, test.rgb), 2, 4, test.negative, false, test.thresholds)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		char := asciiSet[0][0]
+		if char.Simple != test.wantChar {
+			t.Errorf("%v: got %q, want %q", test.name, char.Simple, test.wantChar)
+		}
+		if char.RgbValue != test.wantColor {
+			t.Errorf("%v: got color %v, want %v", test.name, char.RgbValue, test.wantColor)
+		}
+	}
+
+	// Quadrant cells with a full red left half, where only red dots reach 255
+	imgSet := newUniformSet(2, 2, [3]uint32{254, 254, 254})
+	imgSet[0][0].rgbValue = [3]uint32{255, 0, 0}
+	imgSet[1][0].rgbValue = [3]uint32{255, 0, 0}
+
+	asciiSet, err := ConvertToSeparatedDotChars(imgSet, 2, 2, false, false, [3]int{255, 255, 255})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if char := asciiSet[0][0]; char.Simple != "▌" || char.RgbValue != [3]uint32{255, 0, 0} {
+		t.Errorf("red left half: got %q with color %v, want \"▌\" with color [255 0 0]", char.Simple, char.RgbValue)
+	}
+}
+
+func TestBrailleColorFullForegroundAndBackground(t *testing.T) {
+	defer func(level string) { termColorLevel = level }(termColorLevel)
+	termColorLevel = "millions"
+
+	// Bright red pixels above the threshold in the left column, dark blue ones below it in the right
+	cell := make([][]AsciiPixel, 4)
+	for y := range cell {
+		cell[y] = []AsciiPixel{
+			{charDepth: 200, rgbValue: [3]uint32{240, 20, 20}, grayscaleValue: [3]uint32{200, 200, 200}},
+			{charDepth: 40, rgbValue: [3]uint32{10, 10, 90}, grayscaleValue: [3]uint32{40, 40, 40}},
+		}
+	}
+
+	// A cell with highlighted dots of two different reds, averaged into the foreground
+	mixed := make([][]AsciiPixel, 4)
+	for y := range mixed {
+		mixed[y] = []AsciiPixel{
+			{charDepth: 200, rgbValue: [3]uint32{200, 0, 0}},
+			{charDepth: 210, rgbValue: [3]uint32{100, 50, 0}},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		imgSet    [][]AsciiPixel
+		negative  bool
+		grayscale bool
+		wantChar  string
+		wantFg    [3]int
+		wantBg    [3]int
+	}{
+		{"colored", cell, false, false, "⡇", [3]int{240, 20, 20}, [3]int{10, 10, 90}},
+		{"grayscale", cell, false, true, "⡇", [3]int{200, 200, 200}, [3]int{40, 40, 40}},
+
+		// Dots come from the other side of the threshold, and colors are inverted
+		{"negative", cell, true, false, "⢸", [3]int{245, 245, 165}, [3]int{15, 235, 235}},
+
+		// If all dots are highlighted, both colors are the average of the whole cell
+		{"all dots", mixed, false, false, "⣿", [3]int{150, 25, 0}, [3]int{150, 25, 0}},
+	}
+
+	for _, test := range tests {
+		asciiSet, err := ConvertToBrailleColorFullChars(test.imgSet, test.negative, test.grayscale, 128)
+		if err != nil {
+			t.Fatal(err)
+		}
+		char := asciiSet[0][0]
+
+		if char.Simple != test.wantChar {
+			t.Errorf("%v: got %q, want %q", test.name, char.Simple, test.wantChar)
+		}
+
+		wantFg := fmt.Sprintf("\x1b[38;2;%d;%d;%dm", test.wantFg[0], test.wantFg[1], test.wantFg[2])
+		wantBg := fmt.Sprintf("\x1b[48;2;%d;%d;%dm", test.wantBg[0], test.wantBg[1], test.wantBg[2])
+		if !strings.Contains(char.OriginalColor, wantFg) {
+			t.Errorf("%v: %q doesn't set foreground %v", test.name, char.OriginalColor, test.wantFg)
+		}
+		if !strings.Contains(char.OriginalColor, wantBg) {
+			t.Errorf("%v: %q doesn't set background %v", test.name, char.OriginalColor, test.wantBg)
+		}
+
+		wantRgb := [3]uint32{uint32(test.wantFg[0]), uint32(test.wantFg[1]), uint32(test.wantFg[2])}
+		if char.RgbValue != wantRgb {
+			t.Errorf("%v: got RgbValue %v, want foreground %v", test.name, char.RgbValue, wantRgb)
+		}
+	}
+}
+
+func TestQuadrantCharsClosestCoverage(t *testing.T) {
+	tests := []struct {
+		name      string
+		depths    [4]uint32 // Upper left, upper right, lower left and lower right pixels
+		negative  bool
+		wantChar  string
+		wantIndex int
+	}{
+		{"black", [4]uint32{0, 0, 0, 0}, false, " ", 0},
+		{"white", [4]uint32{255, 255, 255, 255}, false, "█", 15},
+		{"diagonal", [4]uint32{255, 0, 0, 255}, false, "▚", 9},
+		{"negative diagonal", [4]uint32{255, 0, 0, 255}, true, "▞", 6},
+		{"quarter gray", [4]uint32{64, 64, 64, 64}, false, "░", 16},
+		{"half gray", [4]uint32{128, 128, 128, 128}, false, "▒", 17},
+		{"three quarter gray", [4]uint32{191, 191, 191, 191}, false, "▓", 18},
+		{"gray left half", [4]uint32{128, 0, 128, 0}, false, "\U0001FB8C", 19},
+		{"white top and gray bottom", [4]uint32{255, 255, 128, 128}, false, "\U0001FB91", 23},
+		{"gray left and white right", [4]uint32{128, 255, 128, 255}, false, "\U0001FB94", 25},
+
+		// Slightly off values still snap to the nearest glyph
+		{"almost white", [4]uint32{240, 250, 245, 235}, false, "█", 15},
+	}
+
+	for _, test := range tests {
+		imgSet := [][]AsciiPixel{
+			{{charDepth: test.depths[0]}, {charDepth: test.depths[1]}},
+			{{charDepth: test.depths[2]}, {charDepth: test.depths[3]}},
+		}
+
+		chars, err := ConvertToQuadrantChars(imgSet, test.negative, false, false, false, [3]int{255, 255, 255})
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		if got := chars[0][0]; got.Simple != test.wantChar || got.Index != test.wantIndex {
+			t.Errorf("%v: got %q with index %v, want %q with index %v", test.name, got.Simple, got.Index, test.wantChar, test.wantIndex)
+		}
+	}
+}
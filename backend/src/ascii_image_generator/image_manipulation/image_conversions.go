@@ -19,6 +19,9 @@ package image_conversions
 import (
 	"image"
 	"image/color"
+	"math"
+
+	"github.com/disintegration/imaging"
 )
 
 type AsciiPixel struct {
@@ -27,16 +30,79 @@ type AsciiPixel struct {
 	rgbValue       [3]uint32
 }
 
+// Further settings of ConvertToAsciiPixels(). The zero value keeps its original behavior, apart from
+// Filter, which resizes with imaging.NearestNeighbor when left empty
+type PixelOptions struct {
+	// Treat the image as single-channel and only read its red channel for each pixel, skipping
+	// grayscale conversion and color extraction altogether. Opaque images are reduced to their
+	// red channel before resizing, so that only one channel is resized
+	AssumeGrayscale bool
+
+	// Set to "legacy2x2" to give each character a 2x2 grid of pixels, like isBraille does with 2x4
+	DotMode string
+
+	// Which value of each pixel is used for charDepth. It can be "luma" (or empty) for combined
+	// grayscale value, "r", "g", "b", "alpha", "max" or "min". It's ignored when AssumeGrayscale
+	// is true or when a dithered image is used for charDepth
+	LuminanceChannel string
+
+	// Semi-transparent pixels are composited over this color before any of their values are read,
+	// although "alpha" LuminanceChannel still uses their original opacity. Black leaves them as they are
+	BlendColor [3]int
+
+	// Terminal size used when neither dimensions, width nor height is set, or when full is true,
+	// as described for GetTerminalSize()
+	TermSize [2]int
+
+	// Filter used for resizing the image. It was always imaging.Lanczos before this was added
+	Filter imaging.ResampleFilter
+
+	// Dither with an ordered Bayer matrix instead of Floyd-Steinberg error diffusion, as described
+	// for ditherImage(). Only used when dither is true
+	OrderedDither bool
+
+	// How many times taller than wide each character is, which keeps the image's aspect ratio when
+	// the number of rows or columns is calculated instead of given through dimensions. 0 uses 2,
+	// the usual shape of terminal characters
+	CellAspect float64
+}
+
 /*
 This function shrinks the passed image according to specified or default dimensions.
 Stores each pixel's grayscale and RGB values in an AsciiPixel instance to simplify
 getting numeric data for ASCII character comparison.
 
-The returned 2D AsciiPixel slice contains each corresponding pixel's values
+The returned 2D AsciiPixel slice contains each corresponding pixel's values.
+Settings added on top of the original arguments are described for PixelOptions
 */
-func ConvertToAsciiPixels(img image.Image, dimensions []int, width, height int, flipX, flipY, full, isBraille, dither bool) ([][]AsciiPixel, error) {
+func ConvertToAsciiPixels(img image.Image, dimensions []int, width, height int, flipX, flipY, full, isBraille, dither bool, opts PixelOptions) ([][]AsciiPixel, error) {
+
+	assumeGrayscale := opts.AssumeGrayscale
+	luminanceChannel := opts.LuminanceChannel
+	blendColor := opts.BlendColor
+
+	cellWidth, cellHeight := 1, 1
+	if isBraille {
+		// Because one braille character has 8 dots (4 rows and 2 columns)
+		cellWidth, cellHeight = 2, 4
+	} else if opts.DotMode == "legacy2x2" {
+		cellWidth, cellHeight = 2, 2
+	}
+	isDotMode := cellWidth > 1
+
+	// For images that are already grayscale, only their red channel is resized instead of all four channels
+	if assumeGrayscale {
+		if gray, ok := redChannelImage(img); ok {
+			img = gray
+		}
+	}
+
+	cellAspect := opts.CellAspect
+	if cellAspect == 0 {
+		cellAspect = 2
+	}
 
-	smallImg, err := resizeImage(img, full, isBraille, dimensions, width, height)
+	smallImg, err := resizeImage(img, full, cellWidth, cellHeight, dimensions, width, height, opts.TermSize, opts.Filter, cellAspect)
 
 	if err != nil {
 		return nil, err
@@ -46,8 +112,8 @@ func ConvertToAsciiPixels(img image.Image, dimensions []int, width, height int,
 	// The colors are kept from original image
 	var ditheredImage image.Image
 
-	if isBraille && dither {
-		ditheredImage = ditherImage(smallImg)
+	if isDotMode && dither {
+		ditheredImage = ditherImage(smallImg, opts.OrderedDither)
 	}
 
 	var imgSet [][]AsciiPixel
@@ -61,6 +127,31 @@ func ConvertToAsciiPixels(img image.Image, dimensions []int, width, height int,
 		for x := b.Min.X; x < b.Max.X; x++ {
 
 			oldPixel := smallImg.At(x, y)
+
+			_, _, _, alpha := oldPixel.RGBA()
+			if alpha != 0xffff && blendColor != [3]int{0, 0, 0} {
+				oldPixel = blendPixel(oldPixel, blendColor)
+			}
+
+			if assumeGrayscale {
+				// Red, green and blue have the same value for grayscale images
+				gray, _, _, _ := oldPixel.RGBA()
+				gray = gray / 257
+
+				charDepth := gray
+				if isDotMode && dither {
+					charDepth, _, _, _ = ditheredImage.At(x, y).RGBA()
+					charDepth = charDepth / 257
+				}
+
+				temp = append(temp, AsciiPixel{
+					charDepth:      charDepth,
+					grayscaleValue: [3]uint32{gray, gray, gray},
+					rgbValue:       [3]uint32{gray, gray, gray},
+				})
+				continue
+			}
+
 			grayPixel := color.GrayModel.Convert(oldPixel)
 
 			r1, g1, b1, _ := grayPixel.RGBA()
@@ -69,7 +160,7 @@ func ConvertToAsciiPixels(img image.Image, dimensions []int, width, height int,
 			g1 = uint32(g1 / 257)
 			b1 = uint32(b1 / 257)
 
-			if isBraille && dither {
+			if isDotMode && dither {
 
 				// Change charDepth if image dithering is applied
 				// 		Note that neither grayscale nor original color values are changed.
@@ -88,6 +179,10 @@ func ConvertToAsciiPixels(img image.Image, dimensions []int, width, height int,
 			g2 = uint32(g2 / 257)
 			b2 = uint32(b2 / 257)
 
+			if luminanceChannel != "" && luminanceChannel != "luma" && !(isDotMode && dither) {
+				charDepth = getChannelDepth(r2, g2, b2, alpha/257, luminanceChannel)
+			}
+
 			temp = append(temp, AsciiPixel{
 				charDepth:      charDepth,
 				grayscaleValue: [3]uint32{r1, g1, b1},
@@ -105,3 +200,183 @@ func ConvertToAsciiPixels(img image.Image, dimensions []int, width, height int,
 
 	return imgSet, nil
 }
+
+/*
+Darkens pixels of imgSet according to their distance from its center, for a faded edge effect.
+Each pixel's values are multiplied by 1 - strength * d², where d is the pixel's distance from
+the center relative to the distance of the corners. With strength 1, corners are fully black
+*/
+func ApplyVignette(imgSet [][]AsciiPixel, strength float64) {
+
+	rows := len(imgSet)
+	if rows == 0 || strength <= 0 {
+		return
+	}
+	cols := len(imgSet[0])
+
+	// Measured from centers of pixels, so that opposite edges are darkened equally
+	centerX := float64(cols-1) / 2
+	centerY := float64(rows-1) / 2
+
+	for y := range imgSet {
+		for x := range imgSet[y] {
+
+			var dx, dy float64
+			if centerX > 0 {
+				dx = (float64(x) - centerX) / centerX
+			}
+			if centerY > 0 {
+				dy = (float64(y) - centerY) / centerY
+			}
+
+			// Squared distance relative to corners, from 0 at center to 1 at corners
+			distance := (dx*dx + dy*dy) / 2
+			factor := 1 - strength*distance
+
+			pixel := &imgSet[y][x]
+			pixel.charDepth = uint32(math.Round(float64(pixel.charDepth) * factor))
+			for i := 0; i < 3; i++ {
+				pixel.grayscaleValue[i] = uint32(math.Round(float64(pixel.grayscaleValue[i]) * factor))
+				pixel.rgbValue[i] = uint32(math.Round(float64(pixel.rgbValue[i]) * factor))
+			}
+		}
+	}
+}
+
+// Returns the mean RGB color of the pixels of imgSet, or black if it has none
+func AverageColor(imgSet [][]AsciiPixel) [3]uint8 {
+
+	var sums [3]uint64
+	var count uint64
+
+	for _, row := range imgSet {
+		for _, pixel := range row {
+			for i := 0; i < 3; i++ {
+				sums[i] += uint64(pixel.rgbValue[i])
+			}
+			count++
+		}
+	}
+
+	var average [3]uint8
+	if count == 0 {
+		return average
+	}
+
+	for i := 0; i < 3; i++ {
+		average[i] = uint8((sums[i] + count/2) / count)
+	}
+
+	return average
+}
+
+/*
+Binarizes the charDepth of each pixel of imgSet to 0 or 255 using Sauvola's method, where the threshold
+of each pixel is computed from the mean and standard deviation of the window x window pixels around it.
+Pixels brighter than their local threshold are set to 255. Colors of pixels are left as they are
+*/
+func ApplyAdaptiveThreshold(imgSet [][]AsciiPixel, window int) {
+
+	rows := len(imgSet)
+	if rows == 0 {
+		return
+	}
+	cols := len(imgSet[0])
+
+	// Integral images of charDepth and its square, with an extra leading row and column of zeros
+	sums := make([][]float64, rows+1)
+	squares := make([][]float64, rows+1)
+	for y := range sums {
+		sums[y] = make([]float64, cols+1)
+		squares[y] = make([]float64, cols+1)
+	}
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			value := float64(imgSet[y][x].charDepth)
+			sums[y+1][x+1] = value + sums[y][x+1] + sums[y+1][x] - sums[y][x]
+			squares[y+1][x+1] = value*value + squares[y][x+1] + squares[y+1][x] - squares[y][x]
+		}
+	}
+
+	const (
+		sensitivity  = 0.2
+		maxDeviation = 128.0
+	)
+	radius := window / 2
+
+	thresholded := make([][]bool, rows)
+	for y := 0; y < rows; y++ {
+		thresholded[y] = make([]bool, cols)
+
+		top := clampIndex(y-radius, rows)
+		bottom := clampIndex(y+radius, rows) + 1
+
+		for x := 0; x < cols; x++ {
+			left := clampIndex(x-radius, cols)
+			right := clampIndex(x+radius, cols) + 1
+
+			count := float64((bottom - top) * (right - left))
+			sum := sums[bottom][right] - sums[top][right] - sums[bottom][left] + sums[top][left]
+			square := squares[bottom][right] - squares[top][right] - squares[bottom][left] + squares[top][left]
+
+			mean := sum / count
+			deviation := math.Sqrt(math.Max(square/count-mean*mean, 0))
+			localThreshold := mean * (1 + sensitivity*(deviation/maxDeviation-1))
+
+			thresholded[y][x] = float64(imgSet[y][x].charDepth) > localThreshold
+		}
+	}
+
+	for y := range imgSet {
+		for x := range imgSet[y] {
+			if thresholded[y][x] {
+				imgSet[y][x].charDepth = 255
+			} else {
+				imgSet[y][x].charDepth = 0
+			}
+		}
+	}
+}
+
+/*
+Fades pixels of imgSet towards black according to maskValues, the mask brightness of each pixel as returned
+by ResizeMask() for the dimensions of imgSet. White mask pixels keep pixels as they are, black ones make them
+fully black and grays are in between
+*/
+func ApplyMask(imgSet [][]AsciiPixel, maskValues [][]uint8) {
+
+	for y := range imgSet {
+		for x := range imgSet[y] {
+			factor := float64(maskValues[y][x]) / MAX_VAL
+
+			pixel := &imgSet[y][x]
+			pixel.charDepth = uint32(math.Round(float64(pixel.charDepth) * factor))
+			for i := 0; i < 3; i++ {
+				pixel.grayscaleValue[i] = uint32(math.Round(float64(pixel.grayscaleValue[i]) * factor))
+				pixel.rgbValue[i] = uint32(math.Round(float64(pixel.rgbValue[i]) * factor))
+			}
+		}
+	}
+}
+
+// Resizes mask to cols x rows through filter and returns the grayscale value of each of its pixels, flipped through flipX and flipY
+func ResizeMask(mask image.Image, cols, rows int, flipX, flipY bool, filter imaging.ResampleFilter) [][]uint8 {
+
+	smallMask := imaging.Resize(mask, cols, rows, filter)
+	if flipX {
+		smallMask = imaging.FlipH(smallMask)
+	}
+	if flipY {
+		smallMask = imaging.FlipV(smallMask)
+	}
+
+	maskValues := make([][]uint8, rows)
+	for y := 0; y < rows; y++ {
+		maskValues[y] = make([]uint8, cols)
+		for x := 0; x < cols; x++ {
+			maskValues[y][x] = color.GrayModel.Convert(smallMask.At(x, y)).(color.Gray).Y
+		}
+	}
+
+	return maskValues
+}
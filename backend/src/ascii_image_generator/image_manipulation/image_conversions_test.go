@@ -0,0 +1,409 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_conversions
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Returns a width x height grayscale gradient stored in an RGBA image, like a decoded grayscale scan
+func newGrayGradient(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gray := uint8((x + y) % 256)
+			img.Set(x, y, color.RGBA{gray, gray, gray, 255})
+		}
+	}
+	return img
+}
+
+// Converts img at its own size, so that each pixel is read as it is
+func convertAtOwnSize(t *testing.T, img image.Image, opts PixelOptions) [][]AsciiPixel {
+	opts.Filter = imaging.NearestNeighbor
+	b := img.Bounds()
+
+	imgSet, err := ConvertToAsciiPixels(img, []int{b.Dx(), b.Dy()}, 0, 0, false, false, false, false, false, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return imgSet
+}
+
+func TestLuminanceChannel(t *testing.T) {
+	// Pixels with the same red value but different green and blue values, and one half transparent pixel
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{200, 10, 60, 255})
+	img.SetNRGBA(1, 0, color.NRGBA{200, 250, 0, 255})
+	img.SetNRGBA(2, 0, color.NRGBA{200, 90, 130, 255})
+	img.SetNRGBA(3, 0, color.NRGBA{0, 0, 0, 128})
+
+	tests := []struct {
+		channel string
+		want    [4]uint32
+	}{
+		{"r", [4]uint32{200, 200, 200, 0}},
+		{"g", [4]uint32{10, 250, 90, 0}},
+		{"b", [4]uint32{60, 0, 130, 0}},
+		{"alpha", [4]uint32{255, 255, 255, 128}},
+		{"max", [4]uint32{200, 250, 200, 0}},
+		{"min", [4]uint32{10, 0, 90, 0}},
+	}
+
+	for _, test := range tests {
+		imgSet := convertAtOwnSize(t, img, PixelOptions{LuminanceChannel: test.channel})
+
+		for x, want := range test.want {
+			if got := imgSet[0][x].charDepth; got != want {
+				t.Errorf("channel %v, pixel %v: got %v, want %v", test.channel, x, got, want)
+			}
+		}
+
+		// Colors are kept whichever channel decides charDepth
+		if imgSet[0][1].rgbValue != [3]uint32{200, 250, 0} {
+			t.Errorf("channel %v: got colors %v, want [200 250 0]", test.channel, imgSet[0][1].rgbValue)
+		}
+	}
+
+	// Luma doesn't depend on red alone
+	imgSet := convertAtOwnSize(t, img, PixelOptions{LuminanceChannel: "luma"})
+	if imgSet[0][0].charDepth == imgSet[0][1].charDepth {
+		t.Errorf("luma: pixels 0 and 1 have the same depth %v", imgSet[0][0].charDepth)
+	}
+}
+
+func TestBlendColor(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{255, 255, 255, 128})
+
+	overBlack := convertAtOwnSize(t, img, PixelOptions{})[0][0]
+	overWhite := convertAtOwnSize(t, img, PixelOptions{BlendColor: [3]int{255, 255, 255}})[0][0]
+
+	if overBlack.rgbValue != [3]uint32{128, 128, 128} {
+		t.Errorf("over black: got colors %v, want [128 128 128]", overBlack.rgbValue)
+	}
+	if overWhite.rgbValue != [3]uint32{255, 255, 255} {
+		t.Errorf("over white: got colors %v, want [255 255 255]", overWhite.rgbValue)
+	}
+	if overBlack.charDepth >= overWhite.charDepth {
+		t.Errorf("got depth %v over black and %v over white, want it darker over black", overBlack.charDepth, overWhite.charDepth)
+	}
+
+	// The alpha channel still reads the opacity of the pixel, not of the blended result
+	alpha := convertAtOwnSize(t, img, PixelOptions{BlendColor: [3]int{255, 255, 255}, LuminanceChannel: "alpha"})[0][0]
+	if alpha.charDepth != 128 {
+		t.Errorf("alpha channel: got depth %v, want 128", alpha.charDepth)
+	}
+}
+
+/*
+Returns a line of dark text on a background that fades from bright on the left to dim on the right, like a
+page photographed under uneven light, as pixels for ApplyAdaptiveThreshold(). Also returns where the text is
+*/
+func newUnevenlyLitText(text string) ([][]AsciiPixel, [][]bool) {
+	drawer := &font.Drawer{Face: basicfont.Face7x13, Src: image.White}
+	width := drawer.MeasureString(text).Ceil() + 8
+
+	mask := image.NewGray(image.Rect(0, 0, width, 21))
+	drawer.Dst = mask
+	drawer.Dot = fixed.P(4, 15)
+	drawer.DrawString(text)
+
+	imgSet := make([][]AsciiPixel, mask.Rect.Dy())
+	inked := make([][]bool, mask.Rect.Dy())
+	for y := range imgSet {
+		imgSet[y] = make([]AsciiPixel, width)
+		inked[y] = make([]bool, width)
+
+		for x := range imgSet[y] {
+			background := 230 - 170*x/(width-1)
+			inked[y][x] = mask.GrayAt(x, y).Y > 127
+
+			depth := background
+			if inked[y][x] {
+				depth = background * 2 / 5
+			}
+			imgSet[y][x].charDepth = uint32(depth)
+		}
+	}
+
+	return imgSet, inked
+}
+
+func TestAdaptiveThresholdOnText(t *testing.T) {
+	imgSet, inked := newUnevenlyLitText("Sauvola keeps text legible")
+
+	// A global threshold loses everything on the dim side, background included
+	dimBackground := imgSet[0][len(imgSet[0])-1].charDepth
+	if dimBackground >= 128 {
+		t.Fatalf("dim side of the background is %v, want it below the default threshold", dimBackground)
+	}
+
+	ApplyAdaptiveThreshold(imgSet, 15)
+
+	var inkPixels, inkKept, paperPixels, paperKept int
+	for y := range imgSet {
+		for x, pixel := range imgSet[y] {
+			if pixel.charDepth != 0 && pixel.charDepth != 255 {
+				t.Fatalf("pixel %v,%v has depth %v, want 0 or 255", x, y, pixel.charDepth)
+			}
+
+			if inked[y][x] {
+				inkPixels++
+				if pixel.charDepth == 0 {
+					inkKept++
+				}
+			} else {
+				paperPixels++
+				if pixel.charDepth == 255 {
+					paperKept++
+				}
+			}
+		}
+	}
+
+	if inkKept*100 < inkPixels*95 {
+		t.Errorf("only %v of %v text pixels became dark", inkKept, inkPixels)
+	}
+	if paperKept*100 < paperPixels*95 {
+		t.Errorf("only %v of %v background pixels became bright", paperKept, paperPixels)
+	}
+}
+
+// Returns charDepth of each pixel of the first row of img resized to cols x 1 through filter
+func resizeRow(t *testing.T, img image.Image, cols int, filter imaging.ResampleFilter) []uint32 {
+	imgSet, err := ConvertToAsciiPixels(img, []int{cols, 1}, 0, 0, false, false, false, false, false, PixelOptions{
+		LuminanceChannel: "r",
+		Filter:           filter,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	depths := make([]uint32, cols)
+	for x, pixel := range imgSet[0] {
+		depths[x] = pixel.charDepth
+	}
+	return depths
+}
+
+func TestResampleFilters(t *testing.T) {
+	// A step from 64 to 192, upscaled to show how each filter fills in between and around it
+	step := image.NewGray(image.Rect(0, 0, 4, 1))
+	for x, value := range []uint8{64, 64, 192, 192} {
+		step.SetGray(x, 0, color.Gray{value})
+	}
+
+	// Single pixel stripes, downscaled to show whether each filter averages them
+	stripes := image.NewGray(image.Rect(0, 0, 8, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 8; x++ {
+			stripes.SetGray(x, y, color.Gray{uint8(255 * ((x + y) % 2))})
+		}
+	}
+
+	tests := []struct {
+		name         string
+		filter       imaging.ResampleFilter
+		intermediate bool // Values between 64 and 192 appear around the step
+		overshoot    bool // Values beyond 64 and 192 appear, i.e. ringing of sharp filters
+		averages     bool // Stripes are averaged to gray
+	}{
+		{"lanczos", imaging.Lanczos, true, true, true},
+		{"catmullrom", imaging.CatmullRom, true, true, true},
+		{"linear", imaging.Linear, true, false, true},
+		{"box", imaging.Box, false, false, true},
+		{"nearest", imaging.NearestNeighbor, false, false, false},
+	}
+
+	overshoots := map[string]uint32{}
+
+	for _, test := range tests {
+		var intermediate bool
+		var overshoot uint32
+
+		for _, depth := range resizeRow(t, step, 16, test.filter) {
+			if depth > 64 && depth < 192 {
+				intermediate = true
+			}
+			if depth < 64 && 64-depth > overshoot {
+				overshoot = 64 - depth
+			}
+			if depth > 192 && depth-192 > overshoot {
+				overshoot = depth - 192
+			}
+		}
+
+		if intermediate != test.intermediate {
+			t.Errorf("%v: got intermediate values %v, want %v", test.name, intermediate, test.intermediate)
+		}
+		if (overshoot > 0) != test.overshoot {
+			t.Errorf("%v: got overshoot of %v, want overshoot %v", test.name, overshoot, test.overshoot)
+		}
+		overshoots[test.name] = overshoot
+
+		for x, depth := range resizeRow(t, stripes, 4, test.filter) {
+			averaged := depth > 64 && depth < 192
+			if averaged != test.averages {
+				t.Errorf("%v: stripes pixel %v has depth %v, want averaged %v", test.name, x, depth, test.averages)
+			}
+		}
+	}
+
+	// Lanczos has the widest kernel of the sharp filters, so it rings the most
+	if overshoots["lanczos"] <= overshoots["catmullrom"] {
+		t.Errorf("got overshoot of %v for lanczos and %v for catmullrom, want more for lanczos", overshoots["lanczos"], overshoots["catmullrom"])
+	}
+}
+
+// Counts pixels outside of changed whose charDepth differs between frames a and b
+func countChangedDots(a, b [][]AsciiPixel, changed image.Rectangle) int {
+	count := 0
+	for y := range a {
+		for x := range a[y] {
+			if !image.Pt(x, y).In(changed) && a[y][x].charDepth != b[y][x].charDepth {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func TestOrderedDitherKeepsStaticRegions(t *testing.T) {
+	first := newGrayGradient(32, 32).(*image.RGBA)
+
+	// Second frame of an animation where only the top left corner changes
+	second := image.NewRGBA(first.Bounds())
+	copy(second.Pix, first.Pix)
+	changed := image.Rect(0, 0, 8, 8)
+	for y := changed.Min.Y; y < changed.Max.Y; y++ {
+		for x := changed.Min.X; x < changed.Max.X; x++ {
+			second.Set(x, y, color.RGBA{200, 200, 200, 255})
+		}
+	}
+
+	convert := func(img image.Image, ordered bool) [][]AsciiPixel {
+		// 16x8 braille characters cover the 32x32 pixels exactly
+		imgSet, err := ConvertToAsciiPixels(img, []int{16, 8}, 0, 0, false, false, false, true, true, PixelOptions{
+			Filter:        imaging.NearestNeighbor,
+			OrderedDither: ordered,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return imgSet
+	}
+
+	if count := countChangedDots(convert(first, true), convert(second, true), changed); count != 0 {
+		t.Errorf("ordered dithering changed %v dots outside of the changed region", count)
+	}
+
+	// Error diffusion carries the change into the rest of the frame, which is what ordered dithering avoids
+	if count := countChangedDots(convert(first, false), convert(second, false), changed); count == 0 {
+		t.Errorf("error diffusion didn't change any dots outside of the changed region")
+	}
+}
+
+func TestResizeGrayMatchesImaging(t *testing.T) {
+	src := image.NewGray(image.Rect(0, 0, 37, 23))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i * 97 % 256)
+	}
+
+	filters := map[string]imaging.ResampleFilter{
+		"lanczos":    imaging.Lanczos,
+		"catmullrom": imaging.CatmullRom,
+		"linear":     imaging.Linear,
+		"box":        imaging.Box,
+		"nearest":    imaging.NearestNeighbor,
+	}
+	sizes := [][2]int{{10, 7}, {80, 50}, {37, 11}, {5, 23}, {1, 1}}
+
+	for name, filter := range filters {
+		for _, size := range sizes {
+			got := resizeGray(src, size[0], size[1], filter)
+			want := imaging.Resize(src, size[0], size[1], filter)
+
+			for y := 0; y < size[1]; y++ {
+				for x := 0; x < size[0]; x++ {
+					if g, w := got.GrayAt(x, y).Y, want.NRGBAAt(x, y).R; g != w {
+						t.Fatalf("%v %vx%v: pixel %v,%v is %v, imaging gives %v", name, size[0], size[1], x, y, g, w)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestAssumeGrayscaleResizesRedChannel(t *testing.T) {
+	img := newGrayGradient(64, 48)
+
+	// The image is opaque, so it's reduced to one channel before resizing
+	if _, ok := redChannelImage(img); !ok {
+		t.Fatal("opaque image wasn't reduced to its red channel")
+	}
+
+	opts := PixelOptions{AssumeGrayscale: true, Filter: imaging.Lanczos}
+	imgSet, err := ConvertToAsciiPixels(img, []int{20, 10}, 0, 0, false, false, false, false, false, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Same values as reading the red channel after resizing the whole image, as was done before
+	resized := imaging.Resize(img, 20, 10, imaging.Lanczos)
+	for y := range imgSet {
+		for x := range imgSet[y] {
+			if got, want := imgSet[y][x].charDepth, uint32(resized.NRGBAAt(x, y).R); got != want {
+				t.Fatalf("pixel %v,%v: charDepth is %v, want %v", x, y, got, want)
+			}
+		}
+	}
+
+	// Transparent pixels keep the full resize, so that their alpha can still be blended
+	translucent := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	translucent.SetNRGBA(0, 0, color.NRGBA{255, 255, 255, 128})
+	if _, ok := redChannelImage(translucent); ok {
+		t.Error("image with transparent pixels was reduced to its red channel")
+	}
+}
+
+func benchmarkGrayscaleConversion(b *testing.B, assumeGrayscale bool) {
+	img := newGrayGradient(1600, 1200)
+	opts := PixelOptions{AssumeGrayscale: assumeGrayscale, Filter: imaging.Lanczos}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ConvertToAsciiPixels(img, []int{800, 600}, 0, 0, false, false, false, false, false, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConvertGrayscaleImage(b *testing.B) {
+	benchmarkGrayscaleConversion(b, false)
+}
+
+func BenchmarkConvertGrayscaleImageAssumed(b *testing.B) {
+	benchmarkGrayscaleConversion(b, true)
+}
@@ -0,0 +1,208 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_conversions
+
+import (
+	"image"
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/disintegration/imaging"
+)
+
+/*
+Returns the red channel of img as a single-channel image, which is all that's read of each pixel when
+PixelOptions.AssumeGrayscale is set. Returns false if img has transparent pixels, since their alpha would
+be lost. Images that are already *image.Gray are returned as they are
+*/
+func redChannelImage(img image.Image) (*image.Gray, bool) {
+
+	if gray, ok := img.(*image.Gray); ok {
+		return gray, true
+	}
+
+	if opaque, ok := img.(interface{ Opaque() bool }); !ok || !opaque.Opaque() {
+		return nil, false
+	}
+
+	b := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+
+	switch src := img.(type) {
+	case *image.RGBA:
+		for y := 0; y < b.Dy(); y++ {
+			row := src.Pix[y*src.Stride : y*src.Stride+b.Dx()*4]
+			out := gray.Pix[y*gray.Stride : y*gray.Stride+b.Dx()]
+			for x := range out {
+				out[x] = row[x*4]
+			}
+		}
+	case *image.NRGBA:
+		for y := 0; y < b.Dy(); y++ {
+			row := src.Pix[y*src.Stride : y*src.Stride+b.Dx()*4]
+			out := gray.Pix[y*gray.Stride : y*gray.Stride+b.Dx()]
+			for x := range out {
+				out[x] = row[x*4]
+			}
+		}
+	default:
+		for y := 0; y < b.Dy(); y++ {
+			for x := 0; x < b.Dx(); x++ {
+				r, _, _, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+				gray.Pix[y*gray.Stride+x] = uint8(r >> 8)
+			}
+		}
+	}
+
+	return gray, true
+}
+
+/*
+Resizes a single-channel image to width x height with filter. This follows imaging.Resize() step for step,
+horizontally and then vertically with the same weights and rounding, so results match resizing the same
+pixels in color, but only one channel is computed instead of four
+*/
+func resizeGray(src *image.Gray, width, height int, filter imaging.ResampleFilter) *image.Gray {
+
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+
+	if filter.Support <= 0 {
+		dst := image.NewGray(image.Rect(0, 0, width, height))
+		dx := float64(srcWidth) / float64(width)
+		dy := float64(srcHeight) / float64(height)
+		for y := 0; y < height; y++ {
+			srcY := int((float64(y) + 0.5) * dy)
+			for x := 0; x < width; x++ {
+				srcX := int((float64(x) + 0.5) * dx)
+				dst.Pix[y*dst.Stride+x] = src.Pix[srcY*src.Stride+srcX]
+			}
+		}
+		return dst
+	}
+
+	if srcWidth != width {
+		src = resizeGrayPass(src, width, srcHeight, grayWeights(width, srcWidth, filter), true)
+	}
+	if srcHeight != height {
+		src = resizeGrayPass(src, width, height, grayWeights(height, srcHeight, filter), false)
+	}
+
+	return src
+}
+
+type grayWeight struct {
+	index  int
+	weight float64
+}
+
+// Same weights as imaging uses for resizing srcSize pixels to dstSize
+func grayWeights(dstSize, srcSize int, filter imaging.ResampleFilter) [][]grayWeight {
+
+	scale := float64(srcSize) / float64(dstSize)
+	step := scale
+	if scale < 1 {
+		scale = 1
+	}
+	radius := math.Ceil(scale * filter.Support)
+
+	weights := make([][]grayWeight, dstSize)
+	for v := 0; v < dstSize; v++ {
+		center := (float64(v)+0.5)*step - 0.5
+
+		begin := int(math.Ceil(center - radius))
+		if begin < 0 {
+			begin = 0
+		}
+		end := int(math.Floor(center + radius))
+		if end > srcSize-1 {
+			end = srcSize - 1
+		}
+
+		var sum float64
+		for u := begin; u <= end; u++ {
+			if w := filter.Kernel((float64(u) - center) / scale); w != 0 {
+				sum += w
+				weights[v] = append(weights[v], grayWeight{u, w})
+			}
+		}
+		if sum != 0 {
+			for i := range weights[v] {
+				weights[v][i].weight /= sum
+			}
+		}
+	}
+
+	return weights
+}
+
+// Resizes src along one axis to width x height, with rows split between goroutines like imaging does
+func resizeGrayPass(src *image.Gray, width, height int, weights [][]grayWeight, horizontal bool) *image.Gray {
+
+	dst := image.NewGray(image.Rect(0, 0, width, height))
+
+	// Lines along the resized axis, i.e. rows for horizontal passes and columns for vertical ones
+	lines, srcStep, srcLineStep, dstStep, dstLineStep := height, 1, src.Stride, 1, dst.Stride
+	if !horizontal {
+		lines, srcStep, srcLineStep, dstStep, dstLineStep = width, src.Stride, 1, dst.Stride, 1
+	}
+
+	procs := runtime.GOMAXPROCS(0)
+	if procs > lines {
+		procs = lines
+	}
+
+	var wg sync.WaitGroup
+	for p := 0; p < procs; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for line := p; line < lines; line += procs {
+				srcOffset := line * srcLineStep
+				dstOffset := line * dstLineStep
+				for i, ws := range weights {
+					// Weighted by opaque alpha and divided by it again like imaging does, since
+					// rounding of halfway values depends on the order of these operations
+					var value, alpha float64
+					for _, w := range ws {
+						aw := 255 * w.weight
+						value += float64(src.Pix[srcOffset+w.index*srcStep]) * aw
+						alpha += aw
+					}
+					if alpha != 0 {
+						dst.Pix[dstOffset+i*dstStep] = clampGray(value * (1 / alpha))
+					}
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	return dst
+}
+
+// Rounds value to the nearest uint8 the way imaging does
+func clampGray(value float64) uint8 {
+	v := int64(value + 0.5)
+	if v > 255 {
+		return 255
+	}
+	if v < 0 {
+		return 0
+	}
+	return uint8(v)
+}
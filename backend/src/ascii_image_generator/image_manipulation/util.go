@@ -17,9 +17,11 @@ limitations under the License.
 package image_conversions
 
 import (
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
+	"math"
 
 	"github.com/TheZoraiz/ascii-image-converter/aic_package/winsize"
 	"github.com/disintegration/imaging"
@@ -27,7 +29,16 @@ import (
 	"github.com/makeworld-the-better-one/dither/v2"
 )
 
-func ditherImage(img image.Image) image.Image {
+// Returned by ConvertToAsciiPixels() for images without any pixels, such as malformed files reporting 0x0 dimensions
+var ErrInvalidImage = errors.New("invalid image")
+
+/*
+Dithers img to black and white with Floyd-Steinberg error diffusion, or with an 8x8 Bayer matrix if ordered is
+true. Error diffusion carries each pixel's error rightwards and downwards, so a change anywhere in an image shifts
+the dots after it. Ordered dithering decides each pixel from its own value and position only, so unchanged parts
+of gif frames keep exactly the same dots
+*/
+func ditherImage(img image.Image, ordered bool) image.Image {
 
 	palette := []color.Color{
 		color.Black,
@@ -35,29 +46,88 @@ func ditherImage(img image.Image) image.Image {
 	}
 
 	d := dither.NewDitherer(palette)
-	d.Matrix = dither.FloydSteinberg
+	if ordered {
+		d.Mapper = dither.Bayer(8, 8, 1.0)
+	} else {
+		d.Matrix = dither.FloydSteinberg
+	}
 
 	return d.DitherCopy(img)
 }
 
-func resizeImage(img image.Image, full, isBraille bool, dimensions []int, width, height int) (image.Image, error) {
+/*
+Returns the terminal width and height in characters. Each positive value of termSize is used instead of
+the detected width or height respectively, and detection is skipped if both are positive
+*/
+func GetTerminalSize(termSize [2]int) (int, int, error) {
+
+	if termSize[0] > 0 && termSize[1] > 0 {
+		return termSize[0], termSize[1], nil
+	}
+
+	terminalWidth, terminalHeight, err := winsize.GetTerminalSize()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if termSize[0] > 0 {
+		terminalWidth = termSize[0]
+	}
+	if termSize[1] > 0 {
+		terminalHeight = termSize[1]
+	}
+
+	return terminalWidth, terminalHeight, nil
+}
+
+// Resizes the image to ascii art dimensions, where each character covers cellWidth by cellHeight pixels
+func resizeImage(img image.Image, full bool, cellWidth, cellHeight int, dimensions []int, width, height int, termSize [2]int, filter imaging.ResampleFilter, cellAspect float64) (image.Image, error) {
+
+	asciiWidth, asciiHeight, err := getAsciiDimensions(img.Bounds().Dx(), img.Bounds().Dy(), full, dimensions, width, height, termSize, cellAspect)
+	if err != nil {
+		return nil, err
+	}
+
+	asciiWidth *= cellWidth
+	asciiHeight *= cellHeight
+
+	if gray, ok := img.(*image.Gray); ok {
+		return resizeGray(gray, asciiWidth, asciiHeight, filter), nil
+	}
+
+	return imaging.Resize(img, asciiWidth, asciiHeight, filter), nil
+}
+
+/*
+Returns the number of columns and rows of ascii art for an image of imgWidth x imgHeight pixels. The full,
+dimensions, width and height arguments are the ones passed to ConvertToAsciiPixels(), which decide the
+dimensions in that order of precedence, and fall back to fitting the terminal size taken from termSize.
+Characters are taken to be twice as tall as they're wide, like they are in most terminals
+*/
+func GetAsciiDimensions(imgWidth, imgHeight int, full bool, dimensions []int, width, height int, termSize [2]int) (int, int, error) {
+	return getAsciiDimensions(imgWidth, imgHeight, full, dimensions, width, height, termSize, 2)
+}
+
+// Works like GetAsciiDimensions(), but for characters cellAspect times as tall as they're wide
+func getAsciiDimensions(imgWidth, imgHeight int, full bool, dimensions []int, width, height int, termSize [2]int, cellAspect float64) (int, int, error) {
 
 	var asciiWidth, asciiHeight int
-	var smallImg image.Image
 
-	imgWidth := float64(img.Bounds().Dx())
-	imgHeight := float64(img.Bounds().Dy())
-	aspectRatio := imgWidth / imgHeight
+	if imgWidth < 1 || imgHeight < 1 {
+		return 0, 0, fmt.Errorf("%w: dimensions are %vx%v", ErrInvalidImage, imgWidth, imgHeight)
+	}
+
+	aspectRatio := float64(imgWidth) / float64(imgHeight)
 
 	if full {
-		terminalWidth, _, err := winsize.GetTerminalSize()
+		terminalWidth, _, err := GetTerminalSize(termSize)
 		if err != nil {
-			return nil, err
+			return 0, 0, err
 		}
 
 		asciiWidth = terminalWidth - 1
 		asciiHeight = int(float64(asciiWidth) / aspectRatio)
-		asciiHeight = int(0.5 * float64(asciiHeight))
+		asciiHeight = int(float64(asciiHeight) / cellAspect)
 
 	} else if (width != 0 || height != 0) && len(dimensions) == 0 {
 		// If either width or height is set and dimensions aren't given
@@ -67,7 +137,7 @@ func resizeImage(img image.Image, full, isBraille bool, dimensions []int, width,
 
 			asciiWidth = width
 			asciiHeight = int(float64(asciiWidth) / aspectRatio)
-			asciiHeight = int(0.5 * float64(asciiHeight))
+			asciiHeight = int(float64(asciiHeight) / cellAspect)
 
 			if asciiHeight == 0 {
 				asciiHeight = 1
@@ -78,33 +148,33 @@ func resizeImage(img image.Image, full, isBraille bool, dimensions []int, width,
 
 			asciiHeight = height
 			asciiWidth = int(float64(asciiHeight) * aspectRatio)
-			asciiWidth = int(2 * float64(asciiWidth))
+			asciiWidth = int(cellAspect * float64(asciiWidth))
 
 			if asciiWidth == 0 {
 				asciiWidth = 1
 			}
 
 		} else {
-			return nil, fmt.Errorf("error: both width and height can't be set. Use dimensions instead")
+			return 0, 0, fmt.Errorf("error: both width and height can't be set. Use dimensions instead")
 		}
 
 	} else if len(dimensions) == 0 {
 		// This condition calculates aspect ratio according to terminal height
 
-		terminalWidth, terminalHeight, err := winsize.GetTerminalSize()
+		terminalWidth, terminalHeight, err := GetTerminalSize(termSize)
 		if err != nil {
-			return nil, err
+			return 0, 0, err
 		}
 
 		asciiHeight = terminalHeight - 1
 		asciiWidth = int(float64(asciiHeight) * aspectRatio)
-		asciiWidth = int(2 * float64(asciiWidth))
+		asciiWidth = int(cellAspect * float64(asciiWidth))
 
 		// If ascii width exceeds terminal width, change ratio with respect to terminal width
 		if asciiWidth >= terminalWidth {
 			asciiWidth = terminalWidth - 1
 			asciiHeight = int(float64(asciiWidth) / aspectRatio)
-			asciiHeight = int(0.5 * float64(asciiHeight))
+			asciiHeight = int(float64(asciiHeight) / cellAspect)
 		}
 
 	} else {
@@ -114,14 +184,15 @@ func resizeImage(img image.Image, full, isBraille bool, dimensions []int, width,
 		asciiHeight = dimensions[1]
 	}
 
-	// Because one braille character has 8 dots (4 rows and 2 columns)
-	if isBraille {
-		asciiWidth *= 2
-		asciiHeight *= 4
+	// Very wide or tall images, or a terminal without dimensions, can round down to nothing
+	if asciiWidth < 1 {
+		asciiWidth = 1
+	}
+	if asciiHeight < 1 {
+		asciiHeight = 1
 	}
-	smallImg = imaging.Resize(img, asciiWidth, asciiHeight, imaging.Lanczos)
 
-	return smallImg, nil
+	return asciiWidth, asciiHeight, nil
 }
 
 func reverse(imgSet [][]AsciiPixel, flipX, flipY bool) [][]AsciiPixel {
@@ -145,17 +216,35 @@ func reverse(imgSet [][]AsciiPixel, flipX, flipY bool) [][]AsciiPixel {
 
 var termColorLevel string = gookitColor.TermColorLevel().String()
 
+// RGB values of the 16 base colors of the terminal theme, from black (0) to bright white (15). If this
+// is set, colored characters use whichever of them is nearest through 16-color escape codes, instead of
+// 24-bit or 8-bit colors
+var Color16Theme *[16][3]int
+
 // This functions calculates terminal color level between rgb colors and 256-colors
 // and returns the character with escape codes appropriately
 func getColoredCharForTerm(r, g, b uint8, char string, background bool) (string, error) {
 	var coloredChar string
 
-	if termColorLevel == "millions" {
+	if Color16Theme != nil {
+		index := Nearest16Color(r, g, b, *Color16Theme)
+
+		// Normal colors are 30-37 and bright ones 90-97, with backgrounds 10 higher
+		code := 30 + index
+		if index >= 8 {
+			code = 90 + index - 8
+		}
+		if background {
+			code += 10
+		}
+		coloredChar = fmt.Sprintf("\x1b[%dm%v\x1b[0m", code, char)
+
+	} else if termColorLevel == "millions" {
 		colorRenderer := gookitColor.RGB(uint8(r), uint8(g), uint8(b), background)
 		coloredChar = colorRenderer.Sprintf("%v", char)
 
 	} else if termColorLevel == "hundreds" {
-		colorRenderer := gookitColor.RGB(uint8(r), uint8(g), uint8(b), background).C256()
+		colorRenderer := gookitColor.C256(Nearest256Color(r, g, b), background)
 		coloredChar = colorRenderer.Sprintf("%v", char)
 
 	} else {
@@ -164,3 +253,257 @@ func getColoredCharForTerm(r, g, b uint8, char string, background bool) (string,
 
 	return coloredChar, nil
 }
+
+// Like getColoredCharForTerm(), but sets both the foreground and background colors of char
+func getDualColoredCharForTerm(fg, bg [3]uint8, char string) (string, error) {
+
+	foreground, err := getColoredCharForTerm(fg[0], fg[1], fg[2], char, false)
+	if err != nil {
+		return "", err
+	}
+
+	return getColoredCharForTerm(bg[0], bg[1], bg[2], foreground, true)
+}
+
+// Levels of each channel in the 6x6x6 color cube of 256-color terminals
+var colorCubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// Returns RGB values of a 256-color terminal palette index from 16 to 255.
+// The first 16 colors are left out since terminal themes usually redefine them
+func color256ToRgb(index int) (int, int, int) {
+	if index >= 232 {
+		gray := 8 + (index-232)*10
+		return gray, gray, gray
+	}
+
+	index -= 16
+	return colorCubeLevels[index/36], colorCubeLevels[(index/6)%6], colorCubeLevels[index%6]
+}
+
+// If true, Nearest256Color() and Nearest16Color() pick the highest palette index among equally close colors
+// instead of the lowest one. Either way, identical colors always map to the same palette index
+var PreferHighestIndex bool
+
+// Number of grayscale ramp entries of the 256-color palette, from index 232 onwards
+const grayRampLength = 24
+
+// Lookup tables for Nearest256Color(), indexed by whether ties go to the highest palette index
+var (
+	// Index in colorCubeLevels of the level closest to each channel value
+	nearestCubeLevel [2][256]int
+
+	// Grayscale ramp entry closest to any color whose channels add up to each sum. The squared distance
+	// from a color to a gray g is r² + g² + b² - 2g(r + g + b) + 3g², so it only depends on that sum
+	nearestRampEntry [2][766]int
+)
+
+func init() {
+	for tie := 0; tie < 2; tie++ {
+		for value := 0; value < 256; value++ {
+			best := -1
+			for level, levelValue := range colorCubeLevels {
+				// Ties keep the earlier level, unless the highest index is preferred
+				if best == -1 || closerOnTie(abs(value-levelValue), abs(value-colorCubeLevels[best]), tie == 1) {
+					best = level
+				}
+			}
+			nearestCubeLevel[tie][value] = best
+		}
+
+		for sum := 0; sum < 766; sum++ {
+			best := -1
+			for entry := 0; entry < grayRampLength; entry++ {
+				if best == -1 || closerOnTie(rampDistance(sum, entry), rampDistance(sum, best), tie == 1) {
+					best = entry
+				}
+			}
+			nearestRampEntry[tie][sum] = best
+		}
+	}
+}
+
+// Returns true if distance should replace bestDistance, which was found at a lower palette index.
+// Equal distances only replace it if the highest index is preferred
+func closerOnTie(distance, bestDistance int, preferHighest bool) bool {
+	return distance < bestDistance || (preferHighest && distance == bestDistance)
+}
+
+// Squared distance from a color whose channels add up to sum to grayscale ramp entry, leaving out r² + g² + b²
+func rampDistance(sum, entry int) int {
+	gray := 8 + entry*10
+	return 3*gray*gray - 2*gray*sum
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+/*
+Nearest256Color returns the 256-color terminal palette index (from 16 to 255) closest to the passed RGB
+value by euclidean distance, considering both the color cube and the grayscale ramp.
+
+If two palette colors are equally close, the lower index is chosen, or the higher one if PreferHighestIndex
+is true, so identical colors map identically across gif frames. Results come from lookup tables built once,
+since this runs for every colored character of every frame
+*/
+func Nearest256Color(r, g, b uint8) uint8 {
+
+	tie := 0
+	if PreferHighestIndex {
+		tie = 1
+	}
+
+	// Squared distances are separable for the cube, so its closest color is the closest level of each channel.
+	// Cube indexes grow with each channel's level, so ties of each channel decide ties of the whole cube
+	red, green, blue := nearestCubeLevel[tie][r], nearestCubeLevel[tie][g], nearestCubeLevel[tie][b]
+	cubeIndex := 16 + 36*red + 6*green + blue
+	cubeDistance := ColorDistance(
+		[3]int{int(r), int(g), int(b)},
+		[3]int{colorCubeLevels[red], colorCubeLevels[green], colorCubeLevels[blue]},
+	)
+
+	rampEntry := nearestRampEntry[tie][int(r)+int(g)+int(b)]
+	gray := 8 + rampEntry*10
+	rampDistance := ColorDistance([3]int{int(r), int(g), int(b)}, [3]int{gray, gray, gray})
+
+	// Ramp indexes are all higher than cube indexes
+	if closerOnTie(rampDistance, cubeDistance, PreferHighestIndex) {
+		return uint8(232 + rampEntry)
+	}
+	return uint8(cubeIndex)
+}
+
+// Returns the index of the color of theme closest to the passed RGB value, keeping the lower index on ties
+// unless PreferHighestIndex is true
+func Nearest16Color(r, g, b uint8, theme [16][3]int) int {
+
+	bestIndex := 0
+	bestDistance := -1
+
+	for index, themeColor := range theme {
+		distance := ColorDistance([3]int{int(r), int(g), int(b)}, themeColor)
+
+		if bestDistance == -1 || closerOnTie(distance, bestDistance, PreferHighestIndex) {
+			bestIndex = index
+			bestDistance = distance
+		}
+	}
+
+	return bestIndex
+}
+
+// ColorDistance returns the squared euclidean distance between two RGB colors
+func ColorDistance(a, b [3]int) int {
+	dr := a[0] - b[0]
+	dg := a[1] - b[1]
+	db := a[2] - b[2]
+	return dr*dr + dg*dg + db*db
+}
+
+// Returns the value of passed channel to be used as charDepth, from 8 bit r, g, b and alpha values
+func getChannelDepth(r, g, b, a uint32, channel string) uint32 {
+	switch channel {
+	case "r":
+		return r
+	case "g":
+		return g
+	case "b":
+		return b
+	case "alpha":
+		return a
+	case "max":
+		maxValue := r
+		if g > maxValue {
+			maxValue = g
+		}
+		if b > maxValue {
+			maxValue = b
+		}
+		return maxValue
+	case "min":
+		minValue := r
+		if g < minValue {
+			minValue = g
+		}
+		if b < minValue {
+			minValue = b
+		}
+		return minValue
+	}
+	return (r + g + b) / 3
+}
+
+// Composites pixel over an opaque background of blendColor. Colors returned by RGBA() are alpha-premultiplied,
+// so the background only needs to be added in proportion to the pixel's transparency
+func blendPixel(pixel color.Color, blendColor [3]int) color.Color {
+	r, g, b, a := pixel.RGBA()
+	transparency := 0xffff - a
+
+	return color.RGBA64{
+		R: uint16(r + uint32(blendColor[0])*257*transparency/0xffff),
+		G: uint16(g + uint32(blendColor[1])*257*transparency/0xffff),
+		B: uint16(b + uint32(blendColor[2])*257*transparency/0xffff),
+		A: 0xffff,
+	}
+}
+
+// Sobel kernel for horizontal change in brightness. Its transpose gives vertical change
+var sobelKernel = [3][3]float64{
+	{-1, 0, 1},
+	{-2, 0, 2},
+	{-1, 0, 1},
+}
+
+/*
+Applies the sobel operator on charDepth of pixels around imgSet[i][j] and returns the edge strength,
+scaled so that a sharp edge between black and white is 255, along with the character closest to
+the edge's direction. Pixels outside imgSet are treated as copies of the nearest edge pixel
+*/
+func getEdgeChar(imgSet [][]AsciiPixel, i, j int) (float64, string) {
+
+	var gx, gy float64
+
+	for di := -1; di <= 1; di++ {
+		for dj := -1; dj <= 1; dj++ {
+			y := clampIndex(i+di, len(imgSet))
+			x := clampIndex(j+dj, len(imgSet[y]))
+
+			depth := float64(imgSet[y][x].charDepth)
+			gx += sobelKernel[di+1][dj+1] * depth
+			gy += sobelKernel[dj+1][di+1] * depth
+		}
+	}
+
+	// Direction of edge, which runs perpendicular to the gradient, from 0 to 180 degrees
+	angle := math.Atan2(gx, gy) * 180 / math.Pi
+	if angle < 0 {
+		angle += 180
+	}
+
+	var char string
+	switch {
+	case angle < 22.5 || angle >= 157.5:
+		char = "-"
+	case angle < 67.5:
+		char = "/"
+	case angle < 112.5:
+		char = "|"
+	default:
+		char = "\\"
+	}
+
+	return math.Sqrt(gx*gx+gy*gy) / 4, char
+}
+
+// Clamps index within 0 and length - 1
+func clampIndex(index, length int) int {
+	if index < 0 {
+		return 0
+	} else if index >= length {
+		return length - 1
+	}
+	return index
+}
@@ -0,0 +1,200 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_conversions
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// Searches every palette index from 16 to 255, as Nearest256Color() is meant to behave
+func bruteForceNearest256(r, g, b uint8, preferHighest bool) uint8 {
+	bestIndex := 16
+	bestDistance := -1
+
+	for index := 16; index < 256; index++ {
+		pr, pg, pb := color256ToRgb(index)
+		distance := ColorDistance([3]int{int(r), int(g), int(b)}, [3]int{pr, pg, pb})
+
+		if bestDistance == -1 || closerOnTie(distance, bestDistance, preferHighest) {
+			bestIndex = index
+			bestDistance = distance
+		}
+	}
+
+	return uint8(bestIndex)
+}
+
+func TestNearest256ColorEquidistant(t *testing.T) {
+	defer func() { PreferHighestIndex = false }()
+
+	tests := []struct {
+		name          string
+		r, g, b       uint8
+		preferHighest bool
+		want          uint8
+	}{
+		// 115 is 20 away from both 95 and 135, which are red levels 1 and 2 of the cube
+		{"red between levels", 115, 0, 0, false, 52},
+		{"red between levels", 115, 0, 0, true, 88},
+
+		// 13 is 5 away from both of the first two ramp entries, 8 and 18, and farther from any cube color
+		{"gray between ramp entries", 13, 13, 13, false, 232},
+		{"gray between ramp entries", 13, 13, 13, true, 233},
+
+		// 4 is 4 away from both cube black and ramp entry 8
+		{"gray between cube and ramp", 4, 4, 4, false, 16},
+		{"gray between cube and ramp", 4, 4, 4, true, 232},
+	}
+
+	for _, test := range tests {
+		PreferHighestIndex = test.preferHighest
+
+		// Repeated, since the same color must always map to the same index
+		for i := 0; i < 3; i++ {
+			if got := Nearest256Color(test.r, test.g, test.b); got != test.want {
+				t.Errorf("%v, prefer highest %v: got %v, want %v", test.name, test.preferHighest, got, test.want)
+			}
+		}
+	}
+}
+
+func TestNearest256ColorMatchesFullSearch(t *testing.T) {
+	defer func() { PreferHighestIndex = false }()
+
+	for _, preferHighest := range []bool{false, true} {
+		PreferHighestIndex = preferHighest
+
+		for r := 0; r < 256; r += 3 {
+			for g := 0; g < 256; g += 5 {
+				for b := 0; b < 256; b += 7 {
+					got := Nearest256Color(uint8(r), uint8(g), uint8(b))
+					want := bruteForceNearest256(uint8(r), uint8(g), uint8(b), preferHighest)
+					if got != want {
+						t.Fatalf("%v,%v,%v, prefer highest %v: got %v, want %v", r, g, b, preferHighest, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestNearest16ColorEquidistant(t *testing.T) {
+	defer func() { PreferHighestIndex = false }()
+
+	var theme [16][3]int
+	for i := range theme {
+		theme[i] = [3]int{255, 255, 255}
+	}
+
+	// Gray 100 is 100 away from both black (0) and gray 200 (9)
+	theme[0] = [3]int{0, 0, 0}
+	theme[9] = [3]int{200, 200, 200}
+
+	if got := Nearest16Color(100, 100, 100, theme); got != 0 {
+		t.Errorf("got %v, want 0", got)
+	}
+
+	PreferHighestIndex = true
+	if got := Nearest16Color(100, 100, 100, theme); got != 9 {
+		t.Errorf("prefer highest: got %v, want 9", got)
+	}
+}
+
+func BenchmarkNearest256Color(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Nearest256Color(uint8(i), uint8(i>>8), uint8(i>>16))
+	}
+}
+
+func TestBlendPixel(t *testing.T) {
+	tests := []struct {
+		name       string
+		pixel      color.Color
+		blendColor [3]int
+		want       color.RGBA
+	}{
+		{"opaque pixel is kept", color.NRGBA{200, 100, 50, 255}, [3]int{255, 255, 255}, color.RGBA{200, 100, 50, 255}},
+		{"transparent pixel becomes background", color.NRGBA{200, 100, 50, 0}, [3]int{10, 20, 30}, color.RGBA{10, 20, 30, 255}},
+		{"half white over black", color.NRGBA{255, 255, 255, 128}, [3]int{0, 0, 0}, color.RGBA{128, 128, 128, 255}},
+		{"half white over white", color.NRGBA{255, 255, 255, 128}, [3]int{255, 255, 255}, color.RGBA{255, 255, 255, 255}},
+		{"half black over red", color.NRGBA{0, 0, 0, 128}, [3]int{255, 0, 0}, color.RGBA{127, 0, 0, 255}},
+
+		// Already premultiplied colors must not be multiplied by alpha again
+		{"premultiplied input", color.RGBA{64, 0, 0, 128}, [3]int{0, 0, 255}, color.RGBA{64, 0, 127, 255}},
+	}
+
+	for _, test := range tests {
+		got := color.RGBAModel.Convert(blendPixel(test.pixel, test.blendColor)).(color.RGBA)
+		if got != test.want {
+			t.Errorf("%v: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGetAsciiDimensionsOfTinyImages(t *testing.T) {
+	tests := []struct {
+		name                string
+		imgWidth, imgHeight int
+		dimensions          []int
+		width, height       int
+		termSize            [2]int
+		wantCols, wantRows  int
+	}{
+		{"1x1 with width", 1, 1, nil, 10, 0, [2]int{}, 10, 5},
+		{"1x1 with height", 1, 1, nil, 0, 3, [2]int{}, 6, 3},
+		{"1x1 with dimensions", 1, 1, []int{7, 4}, 0, 0, [2]int{}, 7, 4},
+		{"1x1 fitting terminal", 1, 1, nil, 0, 0, [2]int{80, 25}, 48, 24},
+
+		// Each of these rounds down to 0 columns or rows before being clamped to 1
+		{"1x1 in 1x1 terminal", 1, 1, nil, 0, 0, [2]int{1, 1}, 1, 1},
+		{"very wide with width", 1000, 1, nil, 10, 0, [2]int{}, 10, 1},
+		{"very tall with height", 1, 1000, nil, 0, 10, [2]int{}, 1, 10},
+	}
+
+	for _, test := range tests {
+		cols, rows, err := GetAsciiDimensions(test.imgWidth, test.imgHeight, false, test.dimensions, test.width, test.height, test.termSize)
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		if cols != test.wantCols || rows != test.wantRows {
+			t.Errorf("%v: got %vx%v, want %vx%v", test.name, cols, rows, test.wantCols, test.wantRows)
+		}
+	}
+
+	for _, size := range [][2]int{{0, 0}, {0, 5}, {5, 0}} {
+		if _, _, err := GetAsciiDimensions(size[0], size[1], false, []int{10, 5}, 0, 0, [2]int{}); !errors.Is(err, ErrInvalidImage) {
+			t.Errorf("%vx%v: got error %v, want ErrInvalidImage", size[0], size[1], err)
+		}
+	}
+
+	// The same checks are reached through ConvertToAsciiPixels()
+	if _, err := ConvertToAsciiPixels(image.NewRGBA(image.Rect(0, 0, 0, 0)), []int{10, 5}, 0, 0, false, false, false, false, false, PixelOptions{}); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("0x0 image: got error %v, want ErrInvalidImage", err)
+	}
+
+	imgSet, err := ConvertToAsciiPixels(image.NewRGBA(image.Rect(0, 0, 1, 1)), nil, 0, 0, false, false, false, false, false, PixelOptions{TermSize: [2]int{1, 1}})
+	if err != nil {
+		t.Fatalf("1x1 image: %v", err)
+	}
+	if len(imgSet) != 1 || len(imgSet[0]) != 1 {
+		t.Errorf("1x1 image in 1x1 terminal: got %v rows, want a single pixel", len(imgSet))
+	}
+}
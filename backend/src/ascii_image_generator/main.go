@@ -30,6 +30,7 @@ func ConvertAscii(filePath *C.char, outPath *C.char) *C.char {
 		Threshold:           128,
 		Dither:              false,
 		OnlySave:            false,
+		AtomicWrites:        true,
 	}
 	fmt.Println("Converted filePath:", C.GoString(filePath))
     result, err := aic_package.Convert(C.GoString(filePath), flags)